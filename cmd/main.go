@@ -26,6 +26,54 @@ func logEnabledMonitoring(config *models.Config) {
 	if config.Monitoring.EnableLoad {
 		enabledFeatures = append(enabledFeatures, "Load")
 	}
+	if config.Monitoring.EnableLogWatch {
+		enabledFeatures = append(enabledFeatures, "LogWatch")
+	}
+	if config.Monitoring.EnableKmsg {
+		enabledFeatures = append(enabledFeatures, "Kmsg")
+	}
+	if config.Monitoring.EnableStorage {
+		enabledFeatures = append(enabledFeatures, "Storage")
+	}
+	if config.Monitoring.EnableInventory {
+		enabledFeatures = append(enabledFeatures, "Inventory")
+	}
+	if config.Monitoring.EnableTimex {
+		enabledFeatures = append(enabledFeatures, "Timex")
+	}
+	if config.Monitoring.EnablePower {
+		enabledFeatures = append(enabledFeatures, "Power")
+	}
+	if config.Monitoring.EnableContainers {
+		enabledFeatures = append(enabledFeatures, "Containers")
+	}
+	if config.Monitoring.EnableInterrupts {
+		enabledFeatures = append(enabledFeatures, "Interrupts")
+	}
+	if config.Monitoring.EnableSchedstat {
+		enabledFeatures = append(enabledFeatures, "Schedstat")
+	}
+	if config.Monitoring.EnableNetworkLink {
+		enabledFeatures = append(enabledFeatures, "NetworkLink")
+	}
+	if config.Monitoring.EnableNUMA {
+		enabledFeatures = append(enabledFeatures, "NUMA")
+	}
+	if config.Monitoring.EnableEDAC {
+		enabledFeatures = append(enabledFeatures, "EDAC")
+	}
+	if config.Monitoring.EnableSessions {
+		enabledFeatures = append(enabledFeatures, "Sessions")
+	}
+	if config.Monitoring.EnableListeners {
+		enabledFeatures = append(enabledFeatures, "Listeners")
+	}
+	if config.Monitoring.EnableIntegrity {
+		enabledFeatures = append(enabledFeatures, "Integrity")
+	}
+	if config.Monitoring.EnableDirWatch {
+		enabledFeatures = append(enabledFeatures, "DirWatch")
+	}
 
 	if len(enabledFeatures) == 0 {
 		slog.Warn("No monitoring features enabled!")
@@ -53,6 +101,7 @@ func main() {
 		"collection_interval", config.CollectionInterval,
 		"database_type", config.Database.Type,
 		"victoria_url", config.GetVictoriaMetricsURL(),
+		"buffer", config.Buffer.Enabled,
 	)
 
 	logEnabledMonitoring(config)
@@ -70,6 +119,12 @@ func main() {
 }
 
 func runMonitoringLoop(ctx context.Context, config *models.Config) error {
+	sink, err := collector.NewSinks(config, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
 	// Create a ticker for periodic collection
 	ticker := time.NewTicker(config.CollectionInterval)
 	defer ticker.Stop()
@@ -78,11 +133,11 @@ func runMonitoringLoop(ctx context.Context, config *models.Config) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	slog.Info("Monitoring loop started", "interval", config.CollectionInterval)
+	slog.Info("Monitoring loop started", "interval", config.CollectionInterval, "sinks", sink.Name())
 
-	// Test VictoriaMetrics connection on startup
-	if err := testVictoriaMetricsConnection(ctx, config); err != nil {
-		slog.Error("VictoriaMetrics connection test failed", "error", err)
+	// Test the backend connections on startup
+	if err := testSinkConnection(ctx, sink); err != nil {
+		slog.Error("Sink connection test failed", "error", err)
 		slog.Info("Will continue trying to send metrics...")
 	}
 
@@ -98,30 +153,28 @@ func runMonitoringLoop(ctx context.Context, config *models.Config) error {
 			return nil
 
 		case <-ticker.C:
-			if err := collectAndSendMetrics(ctx, config); err != nil {
+			if err := collectAndSendMetrics(ctx, config, sink); err != nil {
 				slog.Error("Metric collection failed", "error", err)
 			}
 		}
 	}
 }
 
-func testVictoriaMetricsConnection(ctx context.Context, config *models.Config) error {
-	vmClient := collector.NewVictoriaClient(config.GetVictoriaMetricsURL(), slog.Default())
-
+func testSinkConnection(ctx context.Context, sink collector.Sink) error {
 	testCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	return vmClient.Ping(testCtx)
+	return sink.Ping(testCtx)
 }
 
-func collectAndSendMetrics(ctx context.Context, config *models.Config) error {
+func collectAndSendMetrics(ctx context.Context, config *models.Config, sink collector.Sink) error {
 	start := time.Now()
 
 	// Use a timeout for the collection process
 	collectCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	err := collector.CollectAndSendMetrics(collectCtx, config, slog.Default())
+	err := collector.CollectAndSendMetrics(collectCtx, config, sink, slog.Default())
 
 	duration := time.Since(start)
 	if err != nil {
@@ -0,0 +1,325 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+const bufferFileSuffix = ".batch"
+
+// bufferReplayBatches caps how many queued batches one cycle replays.
+const bufferReplayBatches = 10
+
+// BufferedSink wraps a push sink with a disk-backed write-ahead queue. A batch
+// the sink fails to take is written to the queue and replayed, oldest first
+// and with its original timestamp, once the sink accepts data again. Replay
+// gets at most bufferReplayBatches batches and half of the sink's time per
+// cycle; the current batch is sent after it even while older ones are still
+// queued, so a long backlog does not hold back fresh samples. Delivery is at
+// least once: a batch that failed half way is replayed in full.
+//
+// Batches are stored as series keys and values only. Sinks that route by
+// collector derive it from the series name, so a replayed batch is routed the
+// same way as it would have been live.
+type BufferedSink struct {
+	Sink
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+	logger  *slog.Logger
+
+	mu              sync.Mutex
+	queue           []bufferEntry // oldest first
+	size            int64
+	droppedBatches  uint64
+	droppedSamples  uint64
+	replayedBatches uint64
+}
+
+type bufferEntry struct {
+	path      string
+	timestamp time.Time
+	size      int64
+	samples   int
+}
+
+// NewBufferedSink queues the failed batches of sink below config.Path and
+// picks up batches left from a previous run.
+func NewBufferedSink(sink Sink, config models.BufferConfig, logger *slog.Logger) (*BufferedSink, error) {
+	b := &BufferedSink{
+		Sink:    sink,
+		dir:     filepath.Join(config.Path, sink.Name()),
+		maxSize: config.MaxSize,
+		maxAge:  config.MaxAge,
+		logger:  logger,
+	}
+	if err := os.MkdirAll(b.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating buffer directory: %w", err)
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	if len(b.queue) > 0 {
+		logger.Info("Found buffered batches", "sink", sink.Name(), "batches", len(b.queue), "bytes", b.size)
+	}
+	return b, nil
+}
+
+func (b *BufferedSink) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(time.Now())
+
+	// Older batches go first. Running out of the replay budget is not a
+	// failure of the sink, so the new batch is still sent.
+	replayCtx, cancel := replayContext(ctx)
+	err := b.replay(replayCtx)
+	if errors.Is(err, context.DeadlineExceeded) && replayCtx.Err() != nil && ctx.Err() == nil {
+		err = nil
+	}
+	cancel()
+	if err == nil {
+		if err = b.Sink.SendMetrics(ctx, metrics, timestamp); err == nil {
+			return nil
+		}
+	}
+
+	if qerr := b.enqueue(metrics, timestamp); qerr != nil {
+		return errors.Join(err, fmt.Errorf("buffering batch: %w", qerr))
+	}
+	return fmt.Errorf("%w (batch buffered, %d queued)", err, len(b.queue))
+}
+
+// ClockOffset passes through the offset of the wrapped sink.
+func (b *BufferedSink) ClockOffset() (time.Duration, bool) {
+	if c, ok := b.Sink.(clockOffsetter); ok {
+		return c.ClockOffset()
+	}
+	return 0, false
+}
+
+func (b *BufferedSink) BufferStats() []models.SinkBufferStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := models.SinkBufferStats{
+		Sink:            b.Name(),
+		QueuedBatches:   len(b.queue),
+		QueuedBytes:     b.size,
+		DroppedBatches:  b.droppedBatches,
+		DroppedSamples:  b.droppedSamples,
+		ReplayedBatches: b.replayedBatches,
+	}
+	if len(b.queue) > 0 {
+		stats.OldestAge = time.Since(b.queue[0].timestamp)
+	}
+	return []models.SinkBufferStats{stats}
+}
+
+// replayContext limits replay to half of the time left on ctx.
+func replayContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, time.Now().Add(time.Until(deadline)/2))
+}
+
+// replay sends up to bufferReplayBatches queued batches, stopping early when
+// the queue is empty or the sink fails. Batches the backend rejects are
+// dropped.
+func (b *BufferedSink) replay(ctx context.Context) error {
+	replayed := 0
+	defer func() {
+		if replayed > 0 {
+			b.logger.Info("Replayed buffered batches", "sink", b.Name(), "batches", replayed, "remaining", len(b.queue))
+		}
+	}()
+
+	for len(b.queue) > 0 && replayed < bufferReplayBatches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry := b.queue[0]
+		metrics, err := readBufferFile(entry.path)
+		if err != nil {
+			b.drop("unreadable", err)
+			continue
+		}
+		if err := b.Sink.SendMetrics(ctx, metrics, entry.timestamp); err != nil {
+			if !rejectsBatch(err) {
+				return err
+			}
+			// It would block every batch behind it until it expires.
+			b.drop("rejected", err)
+			continue
+		}
+
+		if err := os.Remove(entry.path); err != nil {
+			b.logger.Warn("Failed to remove replayed batch", "path", entry.path, "error", err)
+		}
+		b.queue = b.queue[1:]
+		b.size -= entry.size
+		b.replayedBatches++
+		replayed++
+	}
+	return nil
+}
+
+// enqueue persists a batch and then drops the oldest batches until the queue
+// fits maxSize again.
+func (b *BufferedSink) enqueue(metrics map[string]float64, timestamp time.Time) error {
+	var buf bytes.Buffer
+	for key, value := range metrics {
+		buf.WriteString(formatSampleValue(value))
+		buf.WriteByte(' ')
+		buf.WriteString(key)
+		buf.WriteByte('\n')
+	}
+	if b.maxSize > 0 && int64(buf.Len()) > b.maxSize {
+		b.droppedBatches++
+		b.droppedSamples += uint64(len(metrics))
+		return fmt.Errorf("batch of %d bytes exceeds the buffer size", buf.Len())
+	}
+
+	nanos := timestamp.UnixNano()
+	path := b.fileFor(nanos)
+	for fileExists(path) {
+		nanos++
+		path = b.fileFor(nanos)
+	}
+
+	// Write under a temporary name so that a crash never leaves a partial
+	// batch to replay.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	b.queue = append(b.queue, bufferEntry{path: path, timestamp: timestamp, size: int64(buf.Len()), samples: len(metrics)})
+	b.size += int64(buf.Len())
+
+	for b.maxSize > 0 && b.size > b.maxSize {
+		b.drop("queue full", nil)
+	}
+	return nil
+}
+
+// expire drops batches older than maxAge.
+func (b *BufferedSink) expire(now time.Time) {
+	for b.maxAge > 0 && len(b.queue) > 0 && now.Sub(b.queue[0].timestamp) > b.maxAge {
+		b.drop("too old", nil)
+	}
+}
+
+// drop discards the oldest batch.
+func (b *BufferedSink) drop(reason string, err error) {
+	entry := b.queue[0]
+	b.queue = b.queue[1:]
+	b.size -= entry.size
+	b.droppedBatches++
+	b.droppedSamples += uint64(entry.samples)
+
+	if rmErr := os.Remove(entry.path); rmErr != nil && !os.IsNotExist(rmErr) {
+		b.logger.Warn("Failed to remove buffered batch", "path", entry.path, "error", rmErr)
+	}
+	b.logger.Warn("Dropped buffered batch", "sink", b.Name(), "reason", reason, "timestamp", entry.timestamp, "samples", entry.samples, "error", err)
+}
+
+func (b *BufferedSink) fileFor(nanos int64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%020d%s", nanos, bufferFileSuffix))
+}
+
+// load rebuilds the queue from the batch files, whose names are their
+// timestamps in nanoseconds, zero padded so that they sort in order.
+func (b *BufferedSink) load() error {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return fmt.Errorf("reading buffer directory: %w", err)
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(b.dir, e.Name())
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			os.Remove(path)
+			continue
+		}
+		name, ok := strings.CutSuffix(e.Name(), bufferFileSuffix)
+		if !ok {
+			continue
+		}
+		nanos, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		metrics, err := readBufferFile(path)
+		if err != nil {
+			b.logger.Warn("Skipping unreadable buffered batch", "path", path, "error", err)
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		b.queue = append(b.queue, bufferEntry{
+			path:      path,
+			timestamp: time.Unix(0, nanos),
+			size:      info.Size(),
+			samples:   len(metrics),
+		})
+		b.size += info.Size()
+	}
+
+	sort.Slice(b.queue, func(i, j int) bool { return b.queue[i].path < b.queue[j].path })
+	return nil
+}
+
+// readBufferFile reads a batch stored as "<value> <series>" lines.
+func readBufferFile(path string) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	metrics := make(map[string]float64)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		value, key, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			return nil, fmt.Errorf("malformed line %q", scanner.Text())
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		metrics[key] = v
+	}
+	return metrics, scanner.Err()
+}
+
+// bufferStatter is implemented by sinks that queue undelivered batches.
+type bufferStatter interface {
+	BufferStats() []models.SinkBufferStats
+}
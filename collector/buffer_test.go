@@ -0,0 +1,305 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+type sentBatch struct {
+	metrics   map[string]float64
+	timestamp time.Time
+}
+
+// recordingSink records every batch it accepts and fails while fail is set.
+type recordingSink struct {
+	mu   sync.Mutex
+	fail bool
+	sent []sentBatch
+	// block, when set, makes sends of matching timestamps wait for the context.
+	block func(time.Time) bool
+	// errFor, when set, returns the error for a batch with this timestamp.
+	errFor func(time.Time) error
+}
+
+func (s *recordingSink) Name() string                   { return "recording" }
+func (s *recordingSink) Ping(ctx context.Context) error { return nil }
+func (s *recordingSink) Close() error                   { return nil }
+
+func (s *recordingSink) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	if s.block != nil && s.block(timestamp) {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("sink unavailable")
+	}
+	if s.errFor != nil {
+		if err := s.errFor(timestamp); err != nil {
+			return err
+		}
+	}
+	s.sent = append(s.sent, sentBatch{metrics, timestamp})
+	return nil
+}
+
+func (s *recordingSink) setFail(fail bool) {
+	s.mu.Lock()
+	s.fail = fail
+	s.mu.Unlock()
+}
+
+func newTestBuffer(t *testing.T, sink Sink, dir string, modify func(*models.BufferConfig)) *BufferedSink {
+	t.Helper()
+	config := models.BufferConfig{Enabled: true, Path: dir, MaxSize: 1 << 20, MaxAge: 24 * time.Hour}
+	if modify != nil {
+		modify(&config)
+	}
+	b, err := NewBufferedSink(sink, config, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func bufferFiles(t *testing.T, b *BufferedSink) []string {
+	t.Helper()
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestBufferedSinkReplaysAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	sink := &recordingSink{fail: true}
+	b := newTestBuffer(t, sink, dir, nil)
+
+	now := time.Now().Truncate(time.Second)
+	for i := range 3 {
+		metrics := map[string]float64{"cpu_usage_percent": float64(i), `disk_free_bytes{mount="/"}`: 1e9 + float64(i)}
+		err := b.SendMetrics(t.Context(), metrics, now.Add(time.Duration(i-3)*time.Minute))
+		if err == nil || !strings.Contains(err.Error(), "batch buffered") {
+			t.Fatalf("SendMetrics error = %v", err)
+		}
+	}
+
+	// A crash during enqueue leaves only a temporary file, which is removed
+	// on load instead of being replayed.
+	if err := os.WriteFile(filepath.Join(b.dir, "00000000000000000001.batch.tmp"), []byte("1 partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b = newTestBuffer(t, sink, dir, nil)
+	if files := bufferFiles(t, b); len(files) != 3 {
+		t.Fatalf("buffer files after load = %v", files)
+	}
+	if stats := b.BufferStats()[0]; stats.QueuedBatches != 3 {
+		t.Fatalf("queued batches = %d, want 3", stats.QueuedBatches)
+	}
+
+	sink.setFail(false)
+	if err := b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 3}, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.sent) != 4 {
+		t.Fatalf("sink received %d batches, want 4", len(sink.sent))
+	}
+	for i, batch := range sink.sent {
+		if want := now.Add(time.Duration(i-3) * time.Minute); !batch.timestamp.Equal(want) {
+			t.Errorf("batch %d timestamp = %v, want %v", i, batch.timestamp, want)
+		}
+		if batch.metrics["cpu_usage_percent"] != float64(i) {
+			t.Errorf("batch %d = %v", i, batch.metrics)
+		}
+	}
+	if v := sink.sent[2].metrics[`disk_free_bytes{mount="/"}`]; v != 1e9+2 {
+		t.Errorf("labelled series replayed as %v", v)
+	}
+	if files := bufferFiles(t, b); len(files) != 0 {
+		t.Errorf("buffer files after replay = %v", files)
+	}
+	if stats := b.BufferStats()[0]; stats.QueuedBatches != 0 || stats.ReplayedBatches != 3 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestBufferedSinkReplayLimit(t *testing.T) {
+	sink := &recordingSink{fail: true}
+	b := newTestBuffer(t, sink, t.TempDir(), nil)
+
+	start := time.Now().Add(-time.Hour)
+	queued := bufferReplayBatches + 5
+	for i := range queued {
+		b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": float64(i)}, start.Add(time.Duration(i)*time.Second))
+	}
+
+	sink.setFail(false)
+	if err := b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": -1}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// The replayed batches come oldest first, then the current one.
+	if len(sink.sent) != bufferReplayBatches+1 || sink.sent[len(sink.sent)-1].metrics["cpu_usage_percent"] != -1 {
+		t.Fatalf("sink received %d batches", len(sink.sent))
+	}
+	for i := range bufferReplayBatches {
+		if sink.sent[i].metrics["cpu_usage_percent"] != float64(i) {
+			t.Errorf("batch %d = %v", i, sink.sent[i].metrics)
+		}
+	}
+	if stats := b.BufferStats()[0]; stats.QueuedBatches != queued-bufferReplayBatches {
+		t.Errorf("queued batches = %d, want %d", stats.QueuedBatches, queued-bufferReplayBatches)
+	}
+}
+
+func TestBufferedSinkReplayBudget(t *testing.T) {
+	current := time.Now()
+	sink := &recordingSink{fail: true}
+	b := newTestBuffer(t, sink, t.TempDir(), nil)
+	b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 1}, current.Add(-time.Minute))
+
+	// Replaying hangs until its deadline; the current batch goes through.
+	sink.block = func(ts time.Time) bool { return !ts.Equal(current) }
+	sink.setFail(false)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+	if err := b.SendMetrics(ctx, map[string]float64{"cpu_usage_percent": 2}, current); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.sent) != 1 || !sink.sent[0].timestamp.Equal(current) {
+		t.Errorf("sink received %+v", sink.sent)
+	}
+	if stats := b.BufferStats()[0]; stats.QueuedBatches != 1 {
+		t.Errorf("queued batches = %d, want the old batch kept", stats.QueuedBatches)
+	}
+}
+
+func TestBufferedSinkReplayErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantSent []float64 // cpu_usage_percent of the delivered batches
+		wantErr  bool
+		queued   int
+		dropped  uint64
+	}{
+		{
+			name:     "rejected batch is dropped",
+			err:      &statusError{code: 400, message: "cannot parse"},
+			wantSent: []float64{0, 2, 3},
+			dropped:  1,
+		},
+		{
+			name:     "failed batch is kept",
+			err:      &statusError{code: 503, message: "unavailable"},
+			wantSent: []float64{0},
+			wantErr:  true,
+			queued:   3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &recordingSink{fail: true}
+			b := newTestBuffer(t, sink, t.TempDir(), nil)
+
+			start := time.Now().Add(-time.Hour)
+			for i := range 3 {
+				b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": float64(i)}, start.Add(time.Duration(i)*time.Minute))
+			}
+			sink.setFail(false)
+			sink.errFor = func(ts time.Time) error {
+				if ts.Equal(start.Add(time.Minute)) {
+					return tt.err
+				}
+				return nil
+			}
+
+			err := b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 3}, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendMetrics error = %v", err)
+			}
+			var sent []float64
+			for _, batch := range sink.sent {
+				sent = append(sent, batch.metrics["cpu_usage_percent"])
+			}
+			if !slices.Equal(sent, tt.wantSent) {
+				t.Errorf("delivered %v, want %v", sent, tt.wantSent)
+			}
+			if stats := b.BufferStats()[0]; stats.QueuedBatches != tt.queued || stats.DroppedBatches != tt.dropped {
+				t.Errorf("stats = %+v", stats)
+			}
+			if len(b.queue) > 0 && !b.queue[0].timestamp.Equal(start.Add(time.Minute)) {
+				t.Errorf("head of the queue is %v", b.queue[0].timestamp)
+			}
+		})
+	}
+}
+
+func TestBufferedSinkEviction(t *testing.T) {
+	batch := map[string]float64{"cpu_usage_percent": 50}
+	// "50 cpu_usage_percent\n" is 21 bytes, so two batches fit.
+	sink := &recordingSink{fail: true}
+	b := newTestBuffer(t, sink, t.TempDir(), func(c *models.BufferConfig) { c.MaxSize = 50 })
+
+	now := time.Now()
+	for i := range 3 {
+		b.SendMetrics(t.Context(), batch, now.Add(time.Duration(i-3)*time.Second))
+	}
+	stats := b.BufferStats()[0]
+	if stats.QueuedBatches != 2 || stats.QueuedBytes != 42 || stats.DroppedBatches != 1 || stats.DroppedSamples != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if b.queue[0].timestamp.Equal(now.Add(-3 * time.Second)) {
+		t.Error("the oldest batch was kept")
+	}
+	if files := bufferFiles(t, b); len(files) != 2 {
+		t.Errorf("buffer files = %v", files)
+	}
+
+	// A batch larger than the whole buffer is rejected and nothing evicted.
+	large := make(map[string]float64)
+	for _, name := range []string{"a", "b", "c"} {
+		large["memory_"+name+"_bytes"] = 1
+	}
+	err := b.SendMetrics(t.Context(), large, now)
+	if err == nil || !strings.Contains(err.Error(), "exceeds the buffer size") {
+		t.Fatalf("oversize batch error = %v", err)
+	}
+	if stats := b.BufferStats()[0]; stats.QueuedBatches != 2 || stats.DroppedBatches != 2 || stats.DroppedSamples != 4 {
+		t.Errorf("stats after oversize batch = %+v", stats)
+	}
+}
+
+func TestBufferedSinkExpiry(t *testing.T) {
+	sink := &recordingSink{fail: true}
+	b := newTestBuffer(t, sink, t.TempDir(), func(c *models.BufferConfig) { c.MaxAge = time.Hour })
+
+	b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 1}, time.Now().Add(-2*time.Hour))
+	b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 2}, time.Now().Add(-time.Minute))
+
+	sink.setFail(false)
+	if err := b.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 3}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.sent) != 2 || sink.sent[0].metrics["cpu_usage_percent"] != 2 {
+		t.Errorf("sink received %+v, want the expired batch dropped", sink.sent)
+	}
+	if stats := b.BufferStats()[0]; stats.DroppedBatches != 1 || stats.ReplayedBatches != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
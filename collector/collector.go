@@ -3,6 +3,10 @@ package collector
 import (
 	"context"
 	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"system-monitoring/models"
 )
@@ -13,28 +17,38 @@ type Collector struct {
 	fn      func() (interface{}, error)
 }
 
-func CollectAndSendMetrics(ctx context.Context, config *models.Config, logger *slog.Logger) error {
+func CollectAndSendMetrics(ctx context.Context, config *models.Config, sink Sink, logger *slog.Logger) error {
 	slog.Info("Starting concurrent collection...")
 
-	vmClient := NewVictoriaClient(config.GetVictoriaMetricsURL(), logger)
-
-	if err := vmClient.Ping(ctx); err != nil {
-		logger.Error("VictoriaMetrics is not accessible", "error", err)
-		return err
+	if err := sink.Ping(ctx); err != nil {
+		logger.Error("Metrics backend is not accessible", "sink", sink.Name(), "error", err)
+		// Without a buffer this cycle's metrics could only be lost.
+		if !config.Buffer.Enabled {
+			return err
+		}
 	}
 
+	timestamp := time.Now()
 	allMetrics, err := CollectAllMetrics(config, logger)
 	if err != nil {
 		return err
 	}
 
+	if config.Monitoring.EnableTimex {
+		addBackendClockDrift(allMetrics, sink, config.Timex.MaxBackendDrift, logger)
+	}
+
+	if config.Buffer.Enabled {
+		addBufferMetrics(allMetrics, sink)
+	}
+
 	if len(allMetrics) > 0 {
-		err = vmClient.SendMetrics(ctx, allMetrics)
+		err = sink.SendMetrics(ctx, allMetrics, timestamp)
 		if err != nil {
-			logger.Error("Failed to send metrics to VictoriaMetrics", "error", err)
+			logger.Error("Failed to send metrics", "sink", sink.Name(), "error", err)
 			return err
 		}
-		logger.Info("Successfully sent metrics to VictoriaMetrics", "count", len(allMetrics))
+		logger.Info("Successfully sent metrics", "sink", sink.Name(), "count", len(allMetrics))
 	} else {
 		logger.Warn("No metrics collected, nothing to send")
 	}
@@ -43,6 +57,46 @@ func CollectAndSendMetrics(ctx context.Context, config *models.Config, logger *s
 	return nil
 }
 
+// addBackendClockDrift records the clock offset against the backend observed
+// by the last Ping and warns when it exceeds maxDrift, since samples carry
+// timestamps from the local clock.
+func addBackendClockDrift(metrics map[string]float64, sink Sink, maxDrift time.Duration, logger *slog.Logger) {
+	c, ok := sink.(clockOffsetter)
+	if !ok {
+		return
+	}
+	offset, ok := c.ClockOffset()
+	if !ok {
+		return
+	}
+
+	drifting := offset > maxDrift || offset < -maxDrift
+	if drifting {
+		logger.Warn("Local clock drifts from the metrics backend", "offset", offset, "max_drift", maxDrift)
+	}
+	metrics["clock_backend_offset_seconds"] = offset.Seconds()
+	metrics["clock_backend_drift_exceeded"] = boolMetric(drifting)
+}
+
+// addBufferMetrics records the state of the write-ahead queues of buffered
+// sinks.
+func addBufferMetrics(metrics map[string]float64, sink Sink) {
+	b, ok := sink.(bufferStatter)
+	if !ok {
+		return
+	}
+
+	for _, stats := range b.BufferStats() {
+		labels := map[string]string{"sink": stats.Sink}
+		metrics[formatMetric("sink_buffer_batches", labels)] = float64(stats.QueuedBatches)
+		metrics[formatMetric("sink_buffer_bytes", labels)] = float64(stats.QueuedBytes)
+		metrics[formatMetric("sink_buffer_oldest_age_seconds", labels)] = stats.OldestAge.Seconds()
+		metrics[formatMetric("sink_buffer_dropped_batches_total", labels)] = float64(stats.DroppedBatches)
+		metrics[formatMetric("sink_buffer_dropped_samples_total", labels)] = float64(stats.DroppedSamples)
+		metrics[formatMetric("sink_buffer_replayed_batches_total", labels)] = float64(stats.ReplayedBatches)
+	}
+}
+
 func CollectAllMetrics(config *models.Config, logger *slog.Logger) (map[string]float64, error) {
 	logger.Debug("Starting metric collection...")
 
@@ -54,6 +108,30 @@ func CollectAllMetrics(config *models.Config, logger *slog.Logger) (map[string]f
 		{"memory", config.Monitoring.EnableMemory, func() (interface{}, error) { return GetMemoryStats() }},
 		{"cpu", config.Monitoring.EnableCPU, func() (interface{}, error) { return GetCpuStats() }},
 		{"load", config.Monitoring.EnableLoad, func() (interface{}, error) { return GetLoadStats() }},
+		{"logwatch", config.Monitoring.EnableLogWatch, func() (interface{}, error) { return GetLogWatchStats(config.LogWatch, logger) }},
+		{"kmsg", config.Monitoring.EnableKmsg, func() (interface{}, error) { return GetKernelEventStats(config.Kmsg, logger) }},
+		{"storage", config.Monitoring.EnableStorage, func() (interface{}, error) {
+			return GetStorageHealthStats(config.Monitoring.ProcPath, config.Monitoring.SysPath)
+		}},
+		{"inventory", config.Monitoring.EnableInventory, func() (interface{}, error) {
+			return GetInventoryStats(config.Monitoring.ProcPath, config.Monitoring.SysPath)
+		}},
+		{"timex", config.Monitoring.EnableTimex, func() (interface{}, error) { return GetTimexStats() }},
+		{"power", config.Monitoring.EnablePower, func() (interface{}, error) { return GetPowerStats(config.Monitoring.SysPath, logger) }},
+		{"containers", config.Monitoring.EnableContainers, func() (interface{}, error) { return GetContainerStats(config.Docker, logger) }},
+		{"interrupts", config.Monitoring.EnableInterrupts, func() (interface{}, error) {
+			return GetInterruptStats(config.Monitoring.ProcPath, config.Interrupts.Aggregation)
+		}},
+		{"schedstat", config.Monitoring.EnableSchedstat, func() (interface{}, error) { return GetSchedStats(config.Monitoring.ProcPath) }},
+		{"network_link", config.Monitoring.EnableNetworkLink, func() (interface{}, error) {
+			return GetNetworkLinkStats(config.Monitoring.ProcPath, config.Monitoring.SysPath)
+		}},
+		{"numa", config.Monitoring.EnableNUMA, func() (interface{}, error) { return GetNUMAStats(config.Monitoring.SysPath) }},
+		{"edac", config.Monitoring.EnableEDAC, func() (interface{}, error) { return GetEDACStats(config.Monitoring.SysPath) }},
+		{"sessions", config.Monitoring.EnableSessions, func() (interface{}, error) { return GetSessionStats(config.Monitoring.UtmpPath) }},
+		{"listeners", config.Monitoring.EnableListeners, func() (interface{}, error) { return GetListenerStats(config.Monitoring.ProcPath, logger) }},
+		{"integrity", config.Monitoring.EnableIntegrity, func() (interface{}, error) { return GetIntegrityStats(config.Integrity, logger) }},
+		{"dirwatch", config.Monitoring.EnableDirWatch, func() (interface{}, error) { return GetDirWatchStats(config.DirWatch, logger) }},
 	}
 
 	enabledCount := 0
@@ -126,7 +204,315 @@ func convertToMetrics(result models.ResultPtr) map[string]float64 {
 			metrics["load_average_5min"] = load.FiveMinAvg
 			metrics["load_average_15min"] = load.FifteenMinAvg
 		}
+	case "logwatch":
+		if logs, ok := result.Data.(*models.LogWatchStats); ok {
+			for _, p := range logs.Patterns {
+				labels := map[string]string{"file": p.File, "pattern": p.Pattern}
+				metrics[formatMetric("log_matches_total", labels)] = float64(p.Matches)
+				if p.Values != nil {
+					addHistogram(metrics, "log_match_value", labels, p.Values)
+				}
+			}
+		}
+	case "kmsg":
+		if kernel, ok := result.Data.(*models.KernelEventStats); ok {
+			for severity, count := range kernel.BySeverity {
+				metrics[formatMetric("kernel_messages_total", map[string]string{"severity": severity})] = float64(count)
+			}
+			for class, count := range kernel.ByClass {
+				metrics[formatMetric("kernel_events_total", map[string]string{"class": class})] = float64(count)
+			}
+		}
+	case "storage":
+		if storage, ok := result.Data.(*models.StorageHealthStats); ok {
+			for _, md := range storage.Arrays {
+				device := map[string]string{"device": md.Name}
+				metrics[formatMetric("md_array_info", map[string]string{"device": md.Name, "state": md.State, "level": md.Level})] = 1
+				metrics[formatMetric("md_disks_required", device)] = float64(md.RequiredDisks)
+				metrics[formatMetric("md_disks", map[string]string{"device": md.Name, "state": "active"})] = float64(md.ActiveDisks)
+				metrics[formatMetric("md_disks", map[string]string{"device": md.Name, "state": "failed"})] = float64(md.FailedDisks)
+				metrics[formatMetric("md_disks", map[string]string{"device": md.Name, "state": "spare"})] = float64(md.SpareDisks)
+
+				degraded := md.ActiveDisks < md.RequiredDisks || md.FailedDisks > 0
+				metrics[formatMetric("md_array_degraded", device)] = boolMetric(degraded)
+
+				if md.SyncAction != "" {
+					metrics[formatMetric("md_sync_progress_percent", map[string]string{"device": md.Name, "action": md.SyncAction})] = md.SyncProgressPct
+				}
+				metrics[formatMetric("md_array_syncing", device)] = boolMetric(md.SyncAction != "")
+			}
+			for _, dev := range storage.Devices {
+				device := map[string]string{"device": dev.Name}
+				metrics[formatMetric("block_device_rotational", device)] = boolMetric(dev.Rotational)
+				metrics[formatMetric("block_device_size_bytes", device)] = float64(dev.SizeBytes)
+			}
+		}
+	case "inventory":
+		if inv, ok := result.Data.(*models.InventoryStats); ok {
+			metrics[formatMetric("node_info", map[string]string{
+				"hostname":       inv.Hostname,
+				"kernel_release": inv.KernelRelease,
+				"os_name":        inv.OSName,
+				"os_version":     inv.OSVersion,
+				"vendor":         inv.SystemVendor,
+				"product":        inv.ProductName,
+			})] = 1
+			metrics[formatMetric("node_cpu_info", map[string]string{"model": inv.CPUModel})] = 1
+			metrics["node_cpu_cores"] = float64(inv.CPUCores)
+			metrics["node_cpu_threads"] = float64(inv.CPUThreads)
+			metrics["node_boot_time_seconds"] = float64(inv.BootTime.Unix())
+			metrics["node_uptime_seconds"] = inv.Uptime.Seconds()
+		}
+	case "timex":
+		if tx, ok := result.Data.(*models.TimexStats); ok {
+			metrics["clock_offset_seconds"] = tx.Offset.Seconds()
+			metrics["clock_frequency_adjustment_ppm"] = tx.FrequencyPPM
+			metrics["clock_max_error_seconds"] = tx.MaxError.Seconds()
+			metrics["clock_estimated_error_seconds"] = tx.EstError.Seconds()
+			metrics["clock_synced"] = boolMetric(tx.Synced)
+		}
+	case "power":
+		if power, ok := result.Data.(*models.PowerStats); ok {
+			for _, supply := range power.Supplies {
+				labels := map[string]string{"supply": supply.Name, "type": strings.ToLower(supply.Type)}
+				if supply.Online != nil {
+					metrics[formatMetric("power_supply_online", labels)] = boolMetric(*supply.Online)
+				}
+				if supply.CapacityPct != nil {
+					metrics[formatMetric("power_supply_capacity_percent", labels)] = *supply.CapacityPct
+				}
+				if supply.VoltageVolts != nil {
+					metrics[formatMetric("power_supply_voltage_volts", labels)] = *supply.VoltageVolts
+				}
+				if supply.CycleCount != nil {
+					metrics[formatMetric("power_supply_cycle_count", labels)] = float64(*supply.CycleCount)
+				}
+				if supply.Status != "" {
+					metrics[formatMetric("power_supply_status", map[string]string{"supply": supply.Name, "status": strings.ToLower(supply.Status)})] = 1
+				}
+			}
+			for _, zone := range power.RAPLZones {
+				labels := map[string]string{"zone": zone.Zone, "name": zone.Name}
+				metrics[formatMetric("rapl_energy_joules_total", labels)] = zone.EnergyJoules
+				if zone.HasPower {
+					metrics[formatMetric("rapl_power_watts", labels)] = zone.PowerWatts
+				}
+			}
+		}
+	case "containers":
+		if containers, ok := result.Data.(*models.ContainersStats); ok {
+			for _, c := range containers.Containers {
+				labels := map[string]string{"name": c.Name, "image": c.Image}
+				metrics[formatMetric("container_cpu_usage_seconds_total", labels)] = c.CPUSeconds
+				metrics[formatMetric("container_cpu_usage_percent", labels)] = c.CPUPercent
+				metrics[formatMetric("container_memory_usage_bytes", labels)] = float64(c.MemoryUsageBytes)
+				metrics[formatMetric("container_memory_working_set_bytes", labels)] = float64(c.MemoryWorkingSetBytes)
+				metrics[formatMetric("container_memory_limit_bytes", labels)] = float64(c.MemoryLimitBytes)
+				metrics[formatMetric("container_network_receive_bytes_total", labels)] = float64(c.NetworkRxBytes)
+				metrics[formatMetric("container_network_transmit_bytes_total", labels)] = float64(c.NetworkTxBytes)
+				metrics[formatMetric("container_blkio_read_bytes_total", labels)] = float64(c.BlockReadBytes)
+				metrics[formatMetric("container_blkio_write_bytes_total", labels)] = float64(c.BlockWriteBytes)
+			}
+			metrics["containers_running"] = float64(len(containers.Containers))
+		}
+	case "interrupts":
+		if irqs, ok := result.Data.(*models.InterruptsStats); ok {
+			for _, c := range irqs.Interrupts {
+				labels := nonEmptyLabels(map[string]string{"irq": c.IRQ, "info": c.Info, "cpu": c.CPU})
+				metrics[formatMetric("interrupts_total", labels)] = float64(c.Count)
+			}
+			for _, c := range irqs.Softirqs {
+				labels := nonEmptyLabels(map[string]string{"type": c.IRQ, "cpu": c.CPU})
+				metrics[formatMetric("softirqs_total", labels)] = float64(c.Count)
+			}
+		}
+	case "schedstat":
+		if sched, ok := result.Data.(*models.SchedStats); ok {
+			for _, c := range sched.CPUs {
+				labels := map[string]string{"cpu": c.CPU}
+				metrics[formatMetric("schedstat_running_seconds_total", labels)] = c.Running.Seconds()
+				metrics[formatMetric("schedstat_waiting_seconds_total", labels)] = c.Waiting.Seconds()
+				metrics[formatMetric("schedstat_timeslices_total", labels)] = float64(c.Timeslices)
+			}
+		}
+	case "network_link":
+		if links, ok := result.Data.(*models.NetworkLinkStats); ok {
+			for _, w := range links.Wireless {
+				labels := map[string]string{"interface": w.Interface}
+				metrics[formatMetric("wireless_link_quality", labels)] = w.Quality
+				metrics[formatMetric("wireless_signal_dbm", labels)] = w.SignalDBm
+				if w.HasNoise {
+					metrics[formatMetric("wireless_noise_dbm", labels)] = w.NoiseDBm
+				}
+			}
+			for _, bond := range links.Bonds {
+				metrics[formatMetric("bonding_up", map[string]string{"bond": bond.Name, "mode": bond.Mode})] = boolMetric(bond.Up)
+				for _, slave := range bond.Slaves {
+					labels := map[string]string{"bond": bond.Name, "slave": slave.Name}
+					metrics[formatMetric("bonding_slave_up", labels)] = boolMetric(slave.Up)
+					metrics[formatMetric("bonding_slave_active", labels)] = boolMetric(slave.Active)
+					metrics[formatMetric("bonding_slave_link_failures_total", labels)] = float64(slave.LinkFailures)
+				}
+			}
+			for _, port := range links.BridgePorts {
+				labels := map[string]string{"bridge": port.Bridge, "port": port.Port}
+				metrics[formatMetric("bridge_port_state", labels)] = float64(port.State)
+				metrics[formatMetric("bridge_port_forwarding", labels)] = boolMetric(port.StateName == "forwarding")
+			}
+		}
+	case "numa":
+		if numa, ok := result.Data.(*models.NUMAStats); ok {
+			for _, node := range numa.Nodes {
+				labels := map[string]string{"node": node.Node}
+				metrics[formatMetric("numa_memory_total_bytes", labels)] = float64(node.Total)
+				metrics[formatMetric("numa_memory_free_bytes", labels)] = float64(node.Free)
+				metrics[formatMetric("numa_memory_used_bytes", labels)] = float64(node.Used)
+				for stat, value := range node.Stats {
+					// numa_hit, numa_miss and numa_foreign carry the prefix
+					// already; local_node, other_node etc. do not.
+					if !strings.HasPrefix(stat, "numa_") {
+						stat = "numa_" + stat
+					}
+					metrics[formatMetric(stat+"_total", labels)] = float64(value)
+				}
+			}
+		}
+	case "edac":
+		if edac, ok := result.Data.(*models.EDACStats); ok {
+			for _, mc := range edac.Controllers {
+				labels := map[string]string{"controller": mc.Controller, "name": mc.Name}
+				metrics[formatMetric("edac_correctable_errors_total", labels)] = float64(mc.Correctable)
+				metrics[formatMetric("edac_uncorrectable_errors_total", labels)] = float64(mc.Uncorrectable)
+
+				unknown := map[string]string{"controller": mc.Controller, "csrow": "unknown"}
+				metrics[formatMetric("edac_csrow_correctable_errors_total", unknown)] = float64(mc.CorrectableNoInfo)
+				metrics[formatMetric("edac_csrow_uncorrectable_errors_total", unknown)] = float64(mc.UncorrectableNoInfo)
+				for _, csrow := range mc.Csrows {
+					csrowLabels := map[string]string{"controller": mc.Controller, "csrow": csrow.Csrow}
+					metrics[formatMetric("edac_csrow_correctable_errors_total", csrowLabels)] = float64(csrow.Correctable)
+					metrics[formatMetric("edac_csrow_uncorrectable_errors_total", csrowLabels)] = float64(csrow.Uncorrectable)
+				}
+			}
+		}
+	case "sessions":
+		if sessions, ok := result.Data.(*models.SessionStats); ok {
+			for _, s := range sessions.Active {
+				metrics[formatMetric("user_sessions_active", map[string]string{"user": s.User, "terminal": s.Terminal})] = float64(s.Sessions)
+			}
+			for user, n := range sessions.Logins {
+				metrics[formatMetric("user_logins_total", map[string]string{"user": user})] = float64(n)
+			}
+		}
+	case "listeners":
+		if listeners, ok := result.Data.(*models.ListenersStats); ok {
+			for _, l := range listeners.Listeners {
+				metrics[formatMetric("listening_socket_info", map[string]string{
+					"protocol": l.Protocol,
+					"address":  l.Address,
+					"port":     strconv.Itoa(l.Port),
+					"process":  l.Process,
+				})] = 1
+			}
+			metrics["listening_sockets"] = float64(len(listeners.Listeners))
+			metrics["listening_sockets_opened_total"] = float64(listeners.Opened)
+			metrics["listening_sockets_closed_total"] = float64(listeners.Closed)
+		}
+	case "integrity":
+		if integrity, ok := result.Data.(*models.IntegrityStats); ok {
+			for _, p := range integrity.Paths {
+				labels := map[string]string{"path": p.Path}
+				metrics[formatMetric("file_integrity_watched_files", labels)] = float64(p.WatchedFiles)
+				metrics[formatMetric("file_integrity_unhashable_files", labels)] = float64(p.UnhashableFiles)
+				metrics[formatMetric("file_integrity_changes_total", labels)] = float64(p.Changes)
+				if !p.LastChangeTime.IsZero() {
+					metrics[formatMetric("file_integrity_last_change_timestamp_seconds", labels)] = float64(p.LastChangeTime.Unix())
+				}
+			}
+		}
+	case "dirwatch":
+		if dirs, ok := result.Data.(*models.DirWatchStats); ok {
+			for _, d := range dirs.Dirs {
+				labels := map[string]string{"path": d.Path, "pattern": d.Pattern}
+				metrics[formatMetric("dir_size_bytes", labels)] = float64(d.SizeBytes)
+				metrics[formatMetric("dir_file_count", labels)] = float64(d.FileCount)
+				metrics[formatMetric("dir_scan_incomplete", labels)] = boolMetric(d.Incomplete)
+				if !d.Newest.IsZero() {
+					metrics[formatMetric("file_newest_age_seconds", labels)] = dirs.Timestamp.Sub(d.Newest).Seconds()
+					metrics[formatMetric("file_oldest_age_seconds", labels)] = dirs.Timestamp.Sub(d.Oldest).Seconds()
+				}
+			}
+		}
 	}
 
 	return metrics
 }
+
+// nonEmptyLabels drops labels without a value, which are omitted from
+// aggregated series.
+func nonEmptyLabels(labels map[string]string) map[string]string {
+	for k, v := range labels {
+		if v == "" {
+			delete(labels, k)
+		}
+	}
+	return labels
+}
+
+// boolMetric converts a flag into a 0/1 gauge value.
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatMetric renders a series key such as log_matches_total{file="a.log",pattern="error"}.
+// Labels are sorted so the same series always produces the same key.
+func formatMetric(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(labelValueEscaper.Replace(labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// addHistogram emits the _bucket, _sum and _count series of a histogram.
+func addHistogram(metrics map[string]float64, name string, labels map[string]string, h *models.HistogramStats) {
+	withLE := func(le string) map[string]string {
+		l := make(map[string]string, len(labels)+1)
+		for k, v := range labels {
+			l[k] = v
+		}
+		l["le"] = le
+		return l
+	}
+
+	for i, bound := range h.Buckets {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		metrics[formatMetric(name+"_bucket", withLE(le))] = float64(h.Counts[i])
+	}
+	metrics[formatMetric(name+"_bucket", withLE("+Inf"))] = float64(h.Count)
+	metrics[formatMetric(name+"_sum", labels)] = h.Sum
+	metrics[formatMetric(name+"_count", labels)] = float64(h.Count)
+}
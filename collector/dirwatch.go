@@ -0,0 +1,195 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+var (
+	errWalkLimit   = errors.New("file limit reached")
+	errWalkTimeout = errors.New("timeout reached")
+	errWalkRunning = errors.New("previous scan still running")
+)
+
+// walkState tracks the walks still running in the background, so that a
+// directory which hangs past its timeout is not walked again every cycle.
+var walkState = struct {
+	sync.Mutex
+	running map[string]bool
+}{running: make(map[string]bool)}
+
+// startWalk claims root for a walk, returning false while an earlier one has
+// not finished.
+func startWalk(root string) bool {
+	walkState.Lock()
+	defer walkState.Unlock()
+	if walkState.running[root] {
+		return false
+	}
+	walkState.running[root] = true
+	return true
+}
+
+func finishWalk(root string) {
+	walkState.Lock()
+	defer walkState.Unlock()
+	delete(walkState.running, root)
+}
+
+// GetDirWatchStats measures every configured directory concurrently, each
+// with its own timeout. A directory that cannot be read at all is logged and
+// reported as an incomplete scan without files, so that alerts on the file
+// count still fire.
+func GetDirWatchStats(config models.DirWatchConfig, logger *slog.Logger) (*models.DirWatchStats, error) {
+	if len(config.Paths) == 0 {
+		return nil, fmt.Errorf("no directories configured")
+	}
+
+	dirs := make([]string, 0, len(config.Paths))
+	for dir := range config.Paths {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	results := make([]*models.DirStats, len(dirs))
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		pattern := strings.TrimSpace(config.Paths[dir])
+		if pattern == "" {
+			pattern = "*"
+		}
+
+		wg.Add(1)
+		go func(i int, dir, pattern string) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+			defer cancel()
+
+			stats, err := walkDir(ctx, dir, pattern, config.MaxFiles, config.MaxDepth)
+			if err != nil {
+				logger.Warn("Failed to scan directory", "path", dir, "error", err)
+				stats = &models.DirStats{Path: dir, Pattern: pattern, Incomplete: true, IncompleteReason: err.Error()}
+			} else if stats.Incomplete {
+				logger.Warn("Directory scan incomplete", "path", dir, "files", stats.FileCount, "reason", stats.IncompleteReason)
+			}
+			results[i] = stats
+		}(i, strings.TrimSpace(dir), pattern)
+	}
+	wg.Wait()
+
+	stats := &models.DirWatchStats{Timestamp: time.Now()}
+	for _, r := range results {
+		stats.Dirs = append(stats.Dirs, *r)
+	}
+	return stats, nil
+}
+
+// walkDir runs the walk in the background so that a directory read which
+// hangs, as on a stale network mount, cannot hold the scan past ctx; the
+// files counted until then are returned. While that walk is still running,
+// later calls for the same root return an incomplete result without files.
+func walkDir(ctx context.Context, root, pattern string, maxFiles, maxDepth int) (*models.DirStats, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if !startWalk(root) {
+		return &models.DirStats{Path: root, Pattern: pattern, Incomplete: true, IncompleteReason: errWalkRunning.Error()}, nil
+	}
+
+	var mu sync.Mutex
+	stats := &models.DirStats{Path: root, Pattern: pattern}
+	rootDepth := strings.Count(filepath.Clean(root), string(filepath.Separator))
+	incomplete := func(reason string) {
+		stats.Incomplete = true
+		if stats.IncompleteReason == "" {
+			stats.IncompleteReason = reason
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if path == root {
+					return err
+				}
+				// Unreadable subdirectories make the result incomplete but
+				// should not hide the rest of the tree.
+				incomplete("unreadable subdirectory")
+				return nil
+			}
+			if ctx.Err() != nil {
+				return errWalkTimeout
+			}
+
+			if d.IsDir() {
+				if strings.Count(filepath.Clean(path), string(filepath.Separator))-rootDepth >= maxDepth {
+					incomplete("depth limit reached")
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if matched, _ := filepath.Match(pattern, d.Name()); !matched {
+				return nil
+			}
+			if stats.FileCount >= maxFiles {
+				return errWalkLimit
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			stats.FileCount++
+			stats.SizeBytes += info.Size()
+
+			modTime := info.ModTime()
+			if stats.Newest.IsZero() || modTime.After(stats.Newest) {
+				stats.Newest = modTime
+			}
+			if stats.Oldest.IsZero() || modTime.Before(stats.Oldest) {
+				stats.Oldest = modTime
+			}
+			return nil
+		})
+		finishWalk(root)
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errWalkTimeout
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if errors.Is(err, errWalkLimit) || errors.Is(err, errWalkTimeout) {
+		incomplete(err.Error())
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The walk may still be running after a timeout; hand out a copy.
+	result := *stats
+	return &result, nil
+}
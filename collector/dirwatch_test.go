@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+func TestWalkDir(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"daily/a.tar.gz":       "aaaa",
+		"daily/b.tar.gz":       "bb",
+		"daily/notes.txt":      "ignored",
+		"weekly/deep/c.tar.gz": "c",
+	})
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(root, "daily/b.tar.gz"), old, old)
+
+	stats, err := walkDir(context.Background(), root, "*.tar.gz", 100, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.FileCount != 3 || stats.SizeBytes != 7 || stats.Incomplete {
+		t.Errorf("stats = %+v, want 3 complete files of 7 bytes", stats)
+	}
+	if !stats.Oldest.Equal(old) {
+		t.Errorf("oldest = %v, want %v", stats.Oldest, old)
+	}
+
+	stats, err = walkDir(context.Background(), root, "*.tar.gz", 2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.FileCount != 2 || stats.IncompleteReason != errWalkLimit.Error() {
+		t.Errorf("with a file limit: %+v", stats)
+	}
+
+	stats, err = walkDir(context.Background(), root, "*.tar.gz", 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.FileCount != 2 || stats.IncompleteReason != "depth limit reached" {
+		t.Errorf("with a depth limit: %+v", stats)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stats, err = walkDir(ctx, root, "*.tar.gz", 100, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.IncompleteReason != errWalkTimeout.Error() {
+		t.Errorf("after the timeout: %+v", stats)
+	}
+}
+
+func TestGetDirWatchStatsWithoutFiles(t *testing.T) {
+	empty := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	stats, err := GetDirWatchStats(models.DirWatchConfig{
+		Paths:    map[string]string{empty: "*.tar.gz", missing: "*"},
+		MaxFiles: 100,
+		MaxDepth: 8,
+		Timeout:  time.Second,
+	}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both directories are reported with no files, so that an alert on the
+	// file count fires; the missing one also as incomplete.
+	if len(stats.Dirs) != 2 {
+		t.Fatalf("got %d directories, want 2", len(stats.Dirs))
+	}
+	for _, d := range stats.Dirs {
+		if d.FileCount != 0 || d.Incomplete != (d.Path == missing) {
+			t.Errorf("unexpected stats %+v", d)
+		}
+	}
+	metrics := convertToMetrics(models.ResultPtr{Type: "dirwatch", Data: stats})
+	key := formatMetric("dir_file_count", map[string]string{"path": empty, "pattern": "*.tar.gz"})
+	if v, ok := metrics[key]; !ok || v != 0 {
+		t.Errorf("%s = %v, %v; want 0", key, v, ok)
+	}
+}
+
+func TestGetDirWatchStatsWalkStillRunning(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"a.log": "a"})
+	config := models.DirWatchConfig{
+		Paths:    map[string]string{root: "*"},
+		MaxFiles: 100,
+		MaxDepth: 8,
+		Timeout:  time.Second,
+	}
+
+	// Stand in for a walk left hanging by an earlier cycle.
+	if !startWalk(root) {
+		t.Fatal("root already being walked")
+	}
+	stats, err := GetDirWatchStats(config, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := stats.Dirs[0]; d.FileCount != 0 || d.IncompleteReason != errWalkRunning.Error() {
+		t.Errorf("while a walk is running: %+v", d)
+	}
+
+	finishWalk(root)
+	stats, err = GetDirWatchStats(config, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := stats.Dirs[0]; d.FileCount != 1 || d.Incomplete {
+		t.Errorf("after the walk finished: %+v", d)
+	}
+}
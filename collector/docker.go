@@ -0,0 +1,222 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// DockerClient talks to the Docker Engine API over its unix socket.
+type DockerClient struct {
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+type dockerContainer struct {
+	ID    string   `json:"Id"`
+	Names []string `json:"Names"`
+	Image string   `json:"Image"`
+}
+
+type dockerCPUStats struct {
+	CPUUsage struct {
+		TotalUsage uint64 `json:"total_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint64 `json:"online_cpus"`
+}
+
+type dockerStats struct {
+	CPUStats    dockerCPUStats `json:"cpu_stats"`
+	PreCPUStats dockerCPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IOServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+}
+
+// dockerStatsWorkers bounds the stats requests in flight, so that a host
+// with hundreds of containers does not open as many connections to the
+// engine at once.
+const dockerStatsWorkers = 8
+
+var (
+	dockerClientOnce sync.Once
+	dockerClient     *DockerClient
+)
+
+func NewDockerClient(socketPath string, timeout time.Duration, logger *slog.Logger) *DockerClient {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+		// Keep an idle connection for each stats worker, so that the next
+		// cycle does not dial again.
+		MaxIdleConnsPerHost: dockerStatsWorkers,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &DockerClient{
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+		logger: logger,
+	}
+}
+
+// GetContainerStats collects through the process-wide Docker client, creating
+// it from config on first use so that connections are reused across cycles.
+func GetContainerStats(config models.DockerConfig, logger *slog.Logger) (*models.ContainersStats, error) {
+	dockerClientOnce.Do(func() {
+		dockerClient = NewDockerClient(config.Socket, config.Timeout, logger)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	return dockerClient.Collect(ctx)
+}
+
+// Collect lists the running containers and fetches their stats with up to
+// dockerStatsWorkers requests at a time, since the engine spends about a
+// second sampling CPU usage for each one. Containers whose stats cannot be
+// read are logged and skipped.
+func (d *DockerClient) Collect(ctx context.Context) (*models.ContainersStats, error) {
+	var containers []dockerContainer
+	if err := d.get(ctx, "/containers/json", &containers); err != nil {
+		return nil, fmt.Errorf("listing containers: %w", err)
+	}
+
+	results := make([]*models.ContainerStats, len(containers))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(dockerStatsWorkers, len(containers)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c := containers[i]
+				stats, err := d.containerStats(ctx, c)
+				if err != nil {
+					d.logger.Warn("Failed to read container stats", "container", c.ID, "error", err)
+					continue
+				}
+				results[i] = stats
+			}
+		}()
+	}
+	for i := range containers {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	stats := &models.ContainersStats{Timestamp: time.Now()}
+	for _, r := range results {
+		if r != nil {
+			stats.Containers = append(stats.Containers, *r)
+		}
+	}
+	return stats, nil
+}
+
+func (d *DockerClient) containerStats(ctx context.Context, c dockerContainer) (*models.ContainerStats, error) {
+	var raw dockerStats
+	if err := d.get(ctx, "/containers/"+c.ID+"/stats?stream=false", &raw); err != nil {
+		return nil, err
+	}
+
+	name := c.ID
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+
+	stats := &models.ContainerStats{
+		ID:               c.ID,
+		Name:             name,
+		Image:            c.Image,
+		CPUSeconds:       float64(raw.CPUStats.CPUUsage.TotalUsage) / 1e9,
+		MemoryUsageBytes: raw.MemoryStats.Usage,
+		MemoryLimitBytes: raw.MemoryStats.Limit,
+	}
+
+	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(raw.CPUStats.SystemUsage) - float64(raw.PreCPUStats.SystemUsage)
+	if raw.PreCPUStats.SystemUsage > 0 && cpuDelta >= 0 && systemDelta > 0 {
+		cpus := float64(raw.CPUStats.OnlineCPUs)
+		if cpus == 0 {
+			cpus = 1
+		}
+		stats.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// Working set excludes reclaimable page cache, as "docker stats" does.
+	// cgroup v2 reports it as inactive_file, cgroup v1 as total_inactive_file.
+	inactive, ok := raw.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		inactive = raw.MemoryStats.Stats["total_inactive_file"]
+	}
+	stats.MemoryWorkingSetBytes = raw.MemoryStats.Usage
+	if inactive < raw.MemoryStats.Usage {
+		stats.MemoryWorkingSetBytes -= inactive
+	}
+
+	for _, network := range raw.Networks {
+		stats.NetworkRxBytes += network.RxBytes
+		stats.NetworkTxBytes += network.TxBytes
+	}
+
+	for _, entry := range raw.BlkioStats.IOServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockReadBytes += entry.Value
+		case "write":
+			stats.BlockWriteBytes += entry.Value
+		}
+	}
+
+	return stats, nil
+}
+
+func (d *DockerClient) get(ctx context.Context, path string, out interface{}) error {
+	// The host part is ignored by the unix socket dialer.
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://docker"+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker engine returned status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
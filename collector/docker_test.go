@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFakeDockerSocket serves canned Engine API responses on a unix socket and
+// counts the connections accepted.
+func newFakeDockerSocket(t *testing.T, routes map[string]string) (string, *atomic.Int64) {
+	t.Helper()
+	return serveDockerSocket(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+func serveDockerSocket(t *testing.T, handler http.Handler) (string, *atomic.Int64) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	var conns atomic.Int64
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return socket, &conns
+}
+
+func TestDockerClientCollect(t *testing.T) {
+	socket, _ := newFakeDockerSocket(t, map[string]string{
+		"/containers/json": `[
+			{"Id": "abc123", "Names": ["/web"], "Image": "nginx:1.25"},
+			{"Id": "gone", "Names": ["/gone"], "Image": "busybox"}
+		]`,
+		"/containers/abc123/stats?stream=false": `{
+			"cpu_stats": {"cpu_usage": {"total_usage": 3000000000}, "system_cpu_usage": 20000000000, "online_cpus": 2},
+			"precpu_stats": {"cpu_usage": {"total_usage": 2000000000}, "system_cpu_usage": 10000000000},
+			"memory_stats": {"usage": 104857600, "limit": 536870912, "stats": {"inactive_file": 4857600}},
+			"networks": {"eth0": {"rx_bytes": 100, "tx_bytes": 200}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}},
+			"blkio_stats": {"io_service_bytes_recursive": [
+				{"op": "Read", "value": 4096}, {"op": "Write", "value": 8192}, {"op": "Total", "value": 12288}
+			]}
+		}`,
+	})
+
+	client := NewDockerClient(socket, 5*time.Second, discardLogger())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := client.Collect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The container whose stats returned 404 is skipped.
+	if len(stats.Containers) != 1 {
+		t.Fatalf("got %d containers, want 1", len(stats.Containers))
+	}
+
+	c := stats.Containers[0]
+	if c.Name != "web" || c.Image != "nginx:1.25" {
+		t.Errorf("name, image = %q, %q", c.Name, c.Image)
+	}
+	if c.CPUSeconds != 3 {
+		t.Errorf("cpu seconds = %v, want 3", c.CPUSeconds)
+	}
+	if c.CPUPercent != 20 {
+		t.Errorf("cpu percent = %v, want 20", c.CPUPercent)
+	}
+	if c.MemoryWorkingSetBytes != 100000000 {
+		t.Errorf("working set = %d, want 100000000", c.MemoryWorkingSetBytes)
+	}
+	if c.NetworkRxBytes != 101 || c.NetworkTxBytes != 202 {
+		t.Errorf("network rx, tx = %d, %d", c.NetworkRxBytes, c.NetworkTxBytes)
+	}
+	if c.BlockReadBytes != 4096 || c.BlockWriteBytes != 8192 {
+		t.Errorf("block read, write = %d, %d", c.BlockReadBytes, c.BlockWriteBytes)
+	}
+}
+
+func TestDockerClientUnavailable(t *testing.T) {
+	client := NewDockerClient(filepath.Join(t.TempDir(), "missing.sock"), time.Second, discardLogger())
+	if _, err := client.Collect(context.Background()); err == nil {
+		t.Fatal("expected an error without a socket")
+	}
+}
+
+func TestDockerClientReusesConnections(t *testing.T) {
+	socket, conns := newFakeDockerSocket(t, map[string]string{
+		"/containers/json":                 `[{"Id": "a"}, {"Id": "b"}, {"Id": "c"}]`,
+		"/containers/a/stats?stream=false": `{}`,
+		"/containers/b/stats?stream=false": `{}`,
+		"/containers/c/stats?stream=false": `{}`,
+	})
+	client := NewDockerClient(socket, 5*time.Second, discardLogger())
+
+	if _, err := client.Collect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	first := conns.Load()
+	for i := 0; i < 5; i++ {
+		if _, err := client.Collect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := conns.Load(); got != first {
+		t.Errorf("%d connections after six cycles, want the %d of the first", got, first)
+	}
+}
+
+func TestDockerClientBoundsConcurrency(t *testing.T) {
+	const containers = 3 * dockerStatsWorkers
+	var inFlight, peak atomic.Int64
+	socket, _ := serveDockerSocket(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/containers/json" {
+			list := make([]string, containers)
+			for i := range list {
+				list[i] = fmt.Sprintf(`{"Id": "c%d"}`, i)
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(list, ","))
+			return
+		}
+
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	client := NewDockerClient(socket, 5*time.Second, discardLogger())
+
+	stats, err := client.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Containers) != containers {
+		t.Errorf("got %d containers, want %d", len(stats.Containers), containers)
+	}
+	if got := peak.Load(); got > dockerStatsWorkers {
+		t.Errorf("%d stats requests in flight, want at most %d", got, dockerStatsWorkers)
+	}
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// GetEDACStats reads the memory error counters of every EDAC memory
+// controller. Hosts without an EDAC driver loaded yield no controllers.
+func GetEDACStats(sysPath string) (*models.EDACStats, error) {
+	controllers, err := filepath.Glob(filepath.Join(sysPath, "devices", "system", "edac", "mc", "mc[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(controllers)
+
+	stats := &models.EDACStats{Timestamp: time.Now()}
+	for _, dir := range controllers {
+		mc := models.EDACControllerStats{
+			Controller: strings.TrimPrefix(filepath.Base(dir), "mc"),
+			Name:       readSysfsString(filepath.Join(dir, "mc_name")),
+		}
+
+		counters := []struct {
+			file  string
+			value *uint64
+		}{
+			{"ce_count", &mc.Correctable},
+			{"ue_count", &mc.Uncorrectable},
+			{"ce_noinfo_count", &mc.CorrectableNoInfo},
+			{"ue_noinfo_count", &mc.UncorrectableNoInfo},
+		}
+		for _, c := range counters {
+			value, err := readSysfsInt(filepath.Join(dir, c.file))
+			if err != nil {
+				return nil, err
+			}
+			*c.value = uint64(value)
+		}
+
+		csrows, err := filepath.Glob(filepath.Join(dir, "csrow[0-9]*"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(csrows)
+
+		for _, csrowDir := range csrows {
+			ce, err := readSysfsInt(filepath.Join(csrowDir, "ce_count"))
+			if err != nil {
+				return nil, err
+			}
+			ue, err := readSysfsInt(filepath.Join(csrowDir, "ue_count"))
+			if err != nil {
+				return nil, err
+			}
+			mc.Csrows = append(mc.Csrows, models.EDACCsrowStats{
+				Csrow:         strings.TrimPrefix(filepath.Base(csrowDir), "csrow"),
+				Correctable:   uint64(ce),
+				Uncorrectable: uint64(ue),
+			})
+		}
+
+		stats.Controllers = append(stats.Controllers, mc)
+	}
+
+	return stats, nil
+}
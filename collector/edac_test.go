@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"system-monitoring/models"
+)
+
+func TestGetEDACStatsFixture(t *testing.T) {
+	sys := t.TempDir()
+	writeTree(t, sys, map[string]string{
+		"devices/system/edac/mc/mc0/mc_name":         "Skylake Socket#0 IMC#0\n",
+		"devices/system/edac/mc/mc0/ce_count":        "7\n",
+		"devices/system/edac/mc/mc0/ue_count":        "1\n",
+		"devices/system/edac/mc/mc0/ce_noinfo_count": "2\n",
+		"devices/system/edac/mc/mc0/ue_noinfo_count": "0\n",
+		"devices/system/edac/mc/mc0/csrow0/ce_count": "5\n",
+		"devices/system/edac/mc/mc0/csrow0/ue_count": "1\n",
+		"devices/system/edac/mc/mc0/csrow1/ce_count": "0\n",
+		"devices/system/edac/mc/mc0/csrow1/ue_count": "0\n",
+		"devices/system/edac/mc/mc1/mc_name":         "Skylake Socket#1 IMC#0\n",
+		"devices/system/edac/mc/mc1/ce_count":        "0\n",
+		"devices/system/edac/mc/mc1/ue_count":        "0\n",
+		"devices/system/edac/mc/mc1/ce_noinfo_count": "0\n",
+		"devices/system/edac/mc/mc1/ue_noinfo_count": "0\n",
+		"devices/system/edac/mc/power/control":       "auto\n",
+	})
+
+	stats, err := GetEDACStats(sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.EDACControllerStats{
+		{
+			Controller:        "0",
+			Name:              "Skylake Socket#0 IMC#0",
+			Correctable:       7,
+			Uncorrectable:     1,
+			CorrectableNoInfo: 2,
+			Csrows: []models.EDACCsrowStats{
+				{Csrow: "0", Correctable: 5, Uncorrectable: 1},
+				{Csrow: "1"},
+			},
+		},
+		{Controller: "1", Name: "Skylake Socket#1 IMC#0"},
+	}
+	if !reflect.DeepEqual(stats.Controllers, want) {
+		t.Errorf("controllers = %+v\nwant %+v", stats.Controllers, want)
+	}
+}
+
+func TestGetEDACStatsWithoutDriver(t *testing.T) {
+	stats, err := GetEDACStats(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Controllers) != 0 {
+		t.Errorf("got %d controllers without EDAC, want none", len(stats.Controllers))
+	}
+}
@@ -0,0 +1,337 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+const fileSinkTimeLayout = "20060102T150405"
+
+// FileSink appends every cycle to a local file, one record per series, as
+// JSON Lines or CSV. Rotated files are renamed to <name>-<rotation time><ext>,
+// optionally gzipped, and pruned down to MaxBackups.
+type FileSink struct {
+	config models.FileSinkConfig
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time
+
+	archive   sync.Mutex // serialises compressing and pruning rotated files
+	archiving sync.WaitGroup
+}
+
+func NewFileSink(config models.FileSinkConfig, logger *slog.Logger) (*FileSink, error) {
+	if config.Format != "jsonl" && config.Format != "csv" {
+		return nil, fmt.Errorf("unsupported file format %q", config.Format)
+	}
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating directory for %s: %w", config.Path, err)
+	}
+
+	f := &FileSink{config: config, logger: logger}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileSink) Name() string {
+	return "file"
+}
+
+func (f *FileSink) Ping(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return fmt.Errorf("%s is not open", f.config.Path)
+	}
+	return nil
+}
+
+func (f *FileSink) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	if len(metrics) == 0 {
+		f.logger.DebugContext(ctx, "No metrics to send")
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		// A previous rotation failed half way.
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	if f.needsRotation(timestamp) {
+		if err := f.rotate(timestamp); err != nil {
+			return err
+		}
+	}
+
+	var data []byte
+	var err error
+	if f.config.Format == "csv" {
+		data, err = formatCSV(metrics, timestamp, f.size == 0)
+	} else {
+		data, err = formatJSONLines(metrics, timestamp)
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", f.config.Path, err)
+	}
+
+	f.logger.DebugContext(ctx, "Wrote metrics to file", "path", f.config.Path, "count", len(metrics), "bytes", n)
+	return nil
+}
+
+// Close closes the file and waits for rotated files to be compressed.
+func (f *FileSink) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.archiving.Wait()
+	return err
+}
+
+// open opens the file for appending. An existing file keeps the rotation
+// period of its last write, so restarts do not postpone time based rotation.
+func (f *FileSink) open() error {
+	file, err := os.OpenFile(f.config.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", f.config.Path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("opening %s: %w", f.config.Path, err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.period = f.periodOf(info.ModTime())
+	return nil
+}
+
+func (f *FileSink) periodOf(t time.Time) time.Time {
+	if f.config.RotateInterval <= 0 {
+		return time.Time{}
+	}
+	return t.Truncate(f.config.RotateInterval)
+}
+
+func (f *FileSink) needsRotation(now time.Time) bool {
+	if f.size == 0 {
+		f.period = f.periodOf(now)
+		return false
+	}
+	if f.config.MaxSize > 0 && f.size >= f.config.MaxSize {
+		return true
+	}
+	return !f.periodOf(now).Equal(f.period)
+}
+
+// rotate moves the current file aside and opens a fresh file. Compressing the
+// rotated file and pruning old ones happen in the background, so that a large
+// file does not hold up the cycle.
+func (f *FileSink) rotate(now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		f.logger.Warn("Failed to close file before rotation", "path", f.config.Path, "error", err)
+	}
+	f.file = nil
+
+	ext := filepath.Ext(f.config.Path)
+	base := strings.TrimSuffix(f.config.Path, ext)
+	rotated := base + "-" + now.Format(fileSinkTimeLayout) + ext
+	for i := 1; fileExists(rotated) || fileExists(rotated+".gz"); i++ {
+		rotated = fmt.Sprintf("%s-%s.%d%s", base, now.Format(fileSinkTimeLayout), i, ext)
+	}
+
+	if err := os.Rename(f.config.Path, rotated); err != nil {
+		return fmt.Errorf("rotating %s: %w", f.config.Path, err)
+	}
+	f.logger.Info("Rotated metrics file", "path", f.config.Path, "rotated", rotated)
+
+	f.archiving.Add(1)
+	go func() {
+		defer f.archiving.Done()
+		f.archive.Lock()
+		defer f.archive.Unlock()
+
+		if f.config.Compress {
+			if err := gzipFile(rotated); err != nil {
+				f.logger.Warn("Failed to compress rotated file", "path", rotated, "error", err)
+			}
+		}
+		if f.config.MaxBackups > 0 {
+			f.pruneBackups(base, ext)
+		}
+	}()
+
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.period = f.periodOf(now)
+	return nil
+}
+
+// pruneBackups removes the oldest rotated files beyond MaxBackups. Only names
+// rotate produces are considered, so unrelated files sharing the prefix stay.
+func (f *FileSink) pruneBackups(base, ext string) {
+	entries, err := os.ReadDir(filepath.Dir(base))
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		path    string
+		rotated time.Time
+		counter int
+	}
+	var backups []backup
+	for _, entry := range entries {
+		rotated, counter, ok := parseBackupName(entry.Name(), filepath.Base(base), ext)
+		if ok && entry.Type().IsRegular() {
+			backups = append(backups, backup{filepath.Join(filepath.Dir(base), entry.Name()), rotated, counter})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].rotated.Equal(backups[j].rotated) {
+			return backups[i].rotated.Before(backups[j].rotated)
+		}
+		return backups[i].counter < backups[j].counter
+	})
+
+	for len(backups) > f.config.MaxBackups {
+		if err := os.Remove(backups[0].path); err != nil {
+			f.logger.Warn("Failed to remove old metrics file", "path", backups[0].path, "error", err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// parseBackupName reports whether name is <base>-<rotation time>[.N]<ext>,
+// optionally gzipped, and returns its rotation time and counter.
+func parseBackupName(name, base, ext string) (time.Time, int, bool) {
+	rest, ok := strings.CutPrefix(name, base+"-")
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	rest = strings.TrimSuffix(rest, ".gz")
+	if rest, ok = strings.CutSuffix(rest, ext); !ok || len(rest) < len(fileSinkTimeLayout) {
+		return time.Time{}, 0, false
+	}
+
+	rotated, err := time.ParseInLocation(fileSinkTimeLayout, rest[:len(fileSinkTimeLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	counter := 0
+	if suffix := rest[len(fileSinkTimeLayout):]; suffix != "" {
+		digits, ok := strings.CutPrefix(suffix, ".")
+		if !ok {
+			return time.Time{}, 0, false
+		}
+		if counter, err = strconv.Atoi(digits); err != nil || counter < 1 || digits[0] == '+' {
+			return time.Time{}, 0, false
+		}
+	}
+	return rotated, counter, true
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	err = errors.Join(err, zw.Close(), out.Close())
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// formatJSONLines writes one JSON object per series. JSON has no NaN or
+// infinities, so such values are skipped.
+func formatJSONLines(metrics map[string]float64, timestamp time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range parseBatch(metrics) {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		sample := newJSONSample(s)
+		sample.Timestamp = timestamp.UnixMilli()
+		if err := enc.Encode(sample); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// formatCSV writes timestamp,name,labels,value records, with labels given as
+// name=value pairs separated by semicolons.
+func formatCSV(metrics map[string]float64, timestamp time.Time, header bool) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if header {
+		w.Write([]string{"timestamp", "name", "labels", "value"})
+	}
+
+	ts := strconv.FormatInt(timestamp.UnixMilli(), 10)
+	for _, s := range parseBatch(metrics) {
+		labels := make([]string, len(s.Labels))
+		for i, l := range s.Labels {
+			labels[i] = l.Name + "=" + l.Value
+		}
+		w.Write([]string{ts, s.Name, strings.Join(labels, ";"), formatSampleValue(s.Value)})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+func TestFileSinkRotatesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.jsonl")
+
+	// Files that share the prefix but were not written by rotate.
+	unrelated := []string{
+		"metrics-archive.jsonl",
+		"metrics-20000101T000000.jsonl.bak",
+		"metrics-20000101T000000-copy.jsonl",
+		"metrics-20000101T000000.x.jsonl",
+	}
+	for _, name := range unrelated {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("keep\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sink, err := NewFileSink(models.FileSinkConfig{
+		Path:       path,
+		Format:     "jsonl",
+		MaxSize:    1, // rotate before every write but the first
+		Compress:   true,
+		MaxBackups: 2,
+	}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	// Two rotations fall into the same second and get a counter.
+	for _, offset := range []time.Duration{0, time.Second, time.Second, 2 * time.Second, 3 * time.Second} {
+		if err := sink.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": offset.Seconds()}, start.Add(offset)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Close waits for the background compression and pruning.
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	want := append([]string{
+		"metrics-20260301T120002.jsonl.gz",
+		"metrics-20260301T120003.jsonl.gz",
+		"metrics.jsonl",
+	}, unrelated...)
+	slices.Sort(want)
+	if !slices.Equal(names, want) {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+
+	// The newest backup holds the write rotated out by the last cycle.
+	file, err := os.Open(filepath.Join(dir, "metrics-20260301T120003.jsonl.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil || !strings.Contains(string(data), `"value":2`) {
+		t.Errorf("newest backup = %q, %v", data, err)
+	}
+}
+
+func TestParseBackupName(t *testing.T) {
+	tests := []struct {
+		name    string
+		counter int
+		ok      bool
+	}{
+		{"metrics-20260301T120000.jsonl", 0, true},
+		{"metrics-20260301T120000.jsonl.gz", 0, true},
+		{"metrics-20260301T120000.3.jsonl.gz", 3, true},
+		{"metrics-20260301T120000.0.jsonl", 0, false},
+		{"metrics-20260301T120000.+1.jsonl", 0, false},
+		{"metrics-20260301T120000.jsonl.bak", 0, false},
+		{"metrics-20261301T120000.jsonl", 0, false},
+		{"metrics-archive.jsonl", 0, false},
+		{"metrics.jsonl", 0, false},
+		{"other-20260301T120000.jsonl", 0, false},
+	}
+	for _, tt := range tests {
+		_, counter, ok := parseBackupName(tt.name, "metrics", ".jsonl")
+		if ok != tt.ok || counter != tt.counter {
+			t.Errorf("parseBackupName(%q) = %d, %v, want %d, %v", tt.name, counter, ok, tt.counter, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// maxDatagramSize keeps UDP packets below a typical Ethernet MTU.
+const maxDatagramSize = 1432
+
+// GraphiteClient sends metrics in the Graphite plaintext protocol over TCP or
+// UDP. A TCP connection is opened per cycle.
+type GraphiteClient struct {
+	config   models.GraphiteConfig
+	template pathTemplate
+	logger   *slog.Logger
+}
+
+func NewGraphiteClient(config models.GraphiteConfig, logger *slog.Logger) (*GraphiteClient, error) {
+	if config.Protocol != "tcp" && config.Protocol != "udp" {
+		return nil, fmt.Errorf("unsupported Graphite protocol %q", config.Protocol)
+	}
+	return &GraphiteClient{
+		config:   config,
+		template: newPathTemplate(config.Prefix, config.Template),
+		logger:   logger,
+	}, nil
+}
+
+func (g *GraphiteClient) Name() string {
+	return "graphite"
+}
+
+// Ping checks that the Graphite listener accepts connections. UDP has no
+// handshake, so only the address is resolved.
+func (g *GraphiteClient) Ping(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, g.config.Protocol, g.config.Address)
+	if err != nil {
+		return fmt.Errorf("connecting to Graphite: %w", err)
+	}
+	return conn.Close()
+}
+
+func (g *GraphiteClient) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	if len(metrics) == 0 {
+		g.logger.DebugContext(ctx, "No metrics to send")
+		return nil
+	}
+
+	var lines []string
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	for _, s := range parseBatch(metrics) {
+		// Carbon cannot store NaN or infinities.
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		path, remaining := g.template.render(s.Name, s.Labels)
+		if g.config.Tags {
+			for _, l := range remaining {
+				path += ";" + graphiteTagEscaper.Replace(l.Name) + "=" + graphiteTagEscaper.Replace(l.Value)
+			}
+		} else {
+			path = appendPathComponents(path, remaining)
+		}
+		lines = append(lines, path+" "+formatSampleValue(s.Value)+" "+ts+"\n")
+	}
+
+	g.logger.DebugContext(ctx, "Sending metrics to Graphite",
+		"count", len(lines),
+		"address", g.config.Address,
+		"protocol", g.config.Protocol)
+
+	_, err := sendLines(ctx, g.config.Protocol, g.config.Address, lines)
+	return err
+}
+
+func (g *GraphiteClient) Close() error {
+	return nil
+}
+
+var graphiteTagEscaper = strings.NewReplacer(";", "_", "~", "_", "=", "_", " ", "_", "\n", "_")
+
+// sendLines writes lines over a new connection. Over UDP lines are packed into
+// datagrams of at most maxDatagramSize bytes, never splitting a line. It
+// returns how many of the lines were written before an error.
+func sendLines(ctx context.Context, network, address string, lines []string) (int, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return 0, fmt.Errorf("connecting to %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(deadline)
+	}
+
+	limit := math.MaxInt
+	if network == "udp" {
+		limit = maxDatagramSize
+	}
+
+	var packet []byte
+	written := 0
+	for i, line := range lines {
+		if len(packet) > 0 && len(packet)+len(line) > limit {
+			if _, err := conn.Write(packet); err != nil {
+				return written, fmt.Errorf("writing to %s: %w", address, err)
+			}
+			packet = packet[:0]
+			written = i
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) > 0 {
+		if _, err := conn.Write(packet); err != nil {
+			return written, fmt.Errorf("writing to %s: %w", address, err)
+		}
+	}
+	return len(lines), nil
+}
+
+// pathTemplate names series as dot separated paths for Graphite and StatsD.
+// Components are either literal or a {host}, {collector}, {name} or {<label>}
+// placeholder; placeholders that resolve to nothing are left out.
+type pathTemplate struct {
+	components []string
+	host       string
+}
+
+func newPathTemplate(prefix, template string) pathTemplate {
+	var components []string
+	for _, part := range strings.Split(prefix+"."+template, ".") {
+		if part != "" {
+			components = append(components, part)
+		}
+	}
+	host, _ := os.Hostname()
+	return pathTemplate{components: components, host: host}
+}
+
+// render returns the path of a series and the labels the template did not use.
+func (t pathTemplate) render(name string, labels []seriesLabel) (string, []seriesLabel) {
+	used := make(map[string]bool)
+	var path []string
+
+	for _, c := range t.components {
+		if !strings.HasPrefix(c, "{") || !strings.HasSuffix(c, "}") {
+			path = append(path, c)
+			continue
+		}
+		var value string
+		switch placeholder := c[1 : len(c)-1]; placeholder {
+		case "host":
+			value = t.host
+		case "collector":
+			value = collectorOf(name)
+		case "name":
+			value = name
+		default:
+			for _, l := range labels {
+				if l.Name == placeholder {
+					value = l.Value
+					used[l.Name] = true
+				}
+			}
+		}
+		if value = sanitizePathComponent(value); value != "" {
+			path = append(path, value)
+		}
+	}
+
+	var remaining []seriesLabel
+	for _, l := range labels {
+		if !used[l.Name] && l.Value != "" {
+			remaining = append(remaining, l)
+		}
+	}
+	return strings.Join(path, "."), remaining
+}
+
+// appendPathComponents appends the label values to path in label order.
+func appendPathComponents(path string, labels []seriesLabel) string {
+	for _, l := range labels {
+		if value := sanitizePathComponent(l.Value); value != "" {
+			path += "." + value
+		}
+	}
+	return path
+}
+
+// sanitizePathComponent replaces everything but letters, digits, '-' and '_'
+// so that a value such as "app.log" or "/dev/sda" stays a single component.
+func sanitizePathComponent(s string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s), "_")
+}
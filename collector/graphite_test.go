@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// graphiteListener accepts one TCP connection and returns what was written
+// to it.
+func graphiteListener(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestGraphiteClientPlaintext(t *testing.T) {
+	metrics := map[string]float64{
+		"cpu_usage_percent": 12.5,
+		"memory_free_mb":    math.NaN(),
+		formatMetric("log_matches_total", map[string]string{"file": "/var/log/app.log", "pattern": "error;x=y"}): 3,
+		formatMetric("md_array_degraded", map[string]string{"device": "md0"}):                                    0,
+	}
+	host, _ := os.Hostname()
+	host = sanitizePathComponent(host)
+
+	tests := []struct {
+		tags bool
+		want []string
+	}{
+		{
+			want: []string{
+				"sysmon." + host + ".cpu.cpu_usage_percent 12.5 1700000000",
+				"sysmon." + host + ".logwatch.log_matches_total.var_log_app_log.error_x_y 3 1700000000",
+				"sysmon." + host + ".storage.md0.md_array_degraded 0 1700000000",
+			},
+		},
+		{
+			tags: true,
+			want: []string{
+				"sysmon." + host + ".cpu.cpu_usage_percent 12.5 1700000000",
+				"sysmon." + host + ".logwatch.log_matches_total;file=/var/log/app.log;pattern=error_x_y 3 1700000000",
+				"sysmon." + host + ".storage.md0.md_array_degraded 0 1700000000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		address, received := graphiteListener(t)
+		client, err := NewGraphiteClient(models.GraphiteConfig{
+			Address:  address,
+			Protocol: "tcp",
+			Prefix:   "sysmon.{host}",
+			Template: "{collector}.{device}.{name}",
+			Tags:     tt.tags,
+		}, discardLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.SendMetrics(t.Context(), metrics, time.Unix(1700000000, 500e6)); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		select {
+		case got = <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("nothing received")
+		}
+		if want := strings.Join(tt.want, "\n") + "\n"; got != want {
+			t.Errorf("tags=%v sent\n%s\nwant\n%s", tt.tags, got, want)
+		}
+	}
+}
+
+func TestGraphiteClientUDPPackets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client, err := NewGraphiteClient(models.GraphiteConfig{
+		Address:  conn.LocalAddr().String(),
+		Protocol: "udp",
+		Prefix:   "sysmon",
+		Template: "{name}",
+	}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := make(map[string]float64)
+	for i := range 100 {
+		metrics[formatMetric("interrupts_total", map[string]string{"irq": fmt.Sprint(i)})] = float64(i)
+	}
+	if err := client.SendMetrics(t.Context(), metrics, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := 0
+	buf := make([]byte, 64*1024)
+	for lines < len(metrics) {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("received %d of %d lines: %v", lines, len(metrics), err)
+		}
+		if n > maxDatagramSize {
+			t.Errorf("datagram of %d bytes", n)
+		}
+		packet := string(buf[:n])
+		if !strings.HasSuffix(packet, "\n") {
+			t.Errorf("datagram splits a line: %q", packet)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(packet, "\n"), "\n") {
+			if !strings.HasPrefix(line, "sysmon.interrupts_total.") || len(strings.Fields(line)) != 3 {
+				t.Errorf("malformed line %q", line)
+			}
+			lines++
+		}
+	}
+	if lines != len(metrics) {
+		t.Errorf("received %d lines, want %d", lines, len(metrics))
+	}
+}
@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// httpDelivery holds the batching and retry behaviour shared by the sinks
+// that push over HTTP.
+type httpDelivery struct {
+	client       *http.Client
+	batchSize    int
+	maxRetries   int
+	retryBackoff time.Duration
+	logger       *slog.Logger
+}
+
+func newHTTPDelivery(config models.DatabaseConfig, logger *slog.Logger) *httpDelivery {
+	return &httpDelivery{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		batchSize:    config.BatchSize,
+		maxRetries:   config.MaxRetries,
+		retryBackoff: config.RetryBackoff,
+		logger:       logger,
+	}
+}
+
+// batches splits the series keys into sorted groups of at most batchSize, so
+// that a large cycle is sent as several bounded requests.
+func (d *httpDelivery) batches(metrics map[string]float64) [][]string {
+	keys := make([]string, 0, len(metrics))
+	for k := range metrics {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	size := d.batchSize
+	if size <= 0 {
+		size = len(keys)
+	}
+
+	var batches [][]string
+	for len(keys) > 0 {
+		n := min(size, len(keys))
+		batches = append(batches, keys[:n])
+		keys = keys[n:]
+	}
+	return batches
+}
+
+// post sends body to url. Network errors, 429 and 5xx responses are retried
+// with exponential backoff; other responses fail immediately.
+func (d *httpDelivery) post(ctx context.Context, url string, header http.Header, body []byte) error {
+	backoff := d.retryBackoff
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = d.postOnce(ctx, url, header, body)
+		if err == nil || !retry || attempt >= d.maxRetries {
+			return err
+		}
+
+		d.logger.DebugContext(ctx, "Retrying request", "url", url, "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (giving up: %w)", err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (d *httpDelivery) postOnce(ctx context.Context, url string, header http.Header, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, &statusError{code: resp.StatusCode, message: string(bytes.TrimSpace(msg))}
+	}
+
+	d.logger.DebugContext(ctx, "Successfully sent metrics", "url", url, "status", resp.Status)
+	return false, nil
+}
+
+// statusError is a non-2xx response from the backend.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server returned status %d: %s", e.code, e.message)
+}
+
+// rejectsBatch reports whether err is the backend refusing the data itself,
+// so that sending the same batch again cannot succeed. Authentication and
+// routing errors are not included: they fail every batch until the
+// configuration is fixed.
+func rejectsBatch(err error) bool {
+	var status *statusError
+	if !errors.As(err, &status) {
+		return false
+	}
+	switch status.code {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// basicAuth returns the Authorization header value for HTTP basic auth.
+func basicAuth(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
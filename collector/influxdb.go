@@ -0,0 +1,203 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	influxKeyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+)
+
+// InfluxClient writes metrics in the InfluxDB line protocol. The collector
+// that produced a series becomes the measurement, its labels the tags and the
+// metric name the field, so the series of one collector sharing a label set
+// are written as a single point.
+type InfluxClient struct {
+	config   models.InfluxDBConfig
+	delivery *httpDelivery
+	logger   *slog.Logger
+}
+
+func NewInfluxClient(config models.InfluxDBConfig, database models.DatabaseConfig, logger *slog.Logger) (*InfluxClient, error) {
+	if config.APIVersion != 1 && config.APIVersion != 2 {
+		return nil, fmt.Errorf("unsupported InfluxDB API version %d", config.APIVersion)
+	}
+	return &InfluxClient{
+		config:   config,
+		delivery: newHTTPDelivery(database, logger),
+		logger:   logger,
+	}, nil
+}
+
+func (c *InfluxClient) Name() string {
+	return "influxdb"
+}
+
+// Ping checks if InfluxDB is accessible. /ping is served by both 1.x and 2.x.
+func (c *InfluxClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.config.URL, "/")+"/ping", nil)
+	if err != nil {
+		return fmt.Errorf("creating ping request: %w", err)
+	}
+
+	resp, err := c.delivery.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ping request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("InfluxDB health check failed: %s", resp.Status)
+	}
+
+	c.logger.InfoContext(ctx, "InfluxDB is healthy")
+	return nil
+}
+
+func (c *InfluxClient) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	if len(metrics) == 0 {
+		c.logger.DebugContext(ctx, "No metrics to send")
+		return nil
+	}
+
+	writeURL, header := c.writeRequest()
+
+	for _, batch := range c.delivery.batches(metrics) {
+		data := formatLineProtocol(batch, metrics, timestamp, c.logger)
+
+		c.logger.DebugContext(ctx, "Sending metrics to InfluxDB",
+			"count", len(batch),
+			"data", data,
+			"url", writeURL)
+
+		if err := c.delivery.post(ctx, writeURL, header, []byte(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *InfluxClient) Close() error {
+	c.delivery.client.CloseIdleConnections()
+	return nil
+}
+
+// writeRequest returns the write endpoint and headers for the configured API
+// version. Timestamps are always sent in milliseconds.
+func (c *InfluxClient) writeRequest() (string, http.Header) {
+	base := strings.TrimSuffix(c.config.URL, "/")
+	query := url.Values{}
+	query.Set("precision", "ms")
+
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	if c.config.APIVersion == 1 {
+		query.Set("db", c.config.Database)
+		if c.config.RetentionPolicy != "" {
+			query.Set("rp", c.config.RetentionPolicy)
+		}
+		if c.config.Username != "" {
+			header.Set("Authorization", basicAuth(c.config.Username, c.config.Password))
+		}
+		return base + "/write?" + query.Encode(), header
+	}
+
+	query.Set("org", c.config.Org)
+	query.Set("bucket", c.config.Bucket)
+	if c.config.Token != "" {
+		header.Set("Authorization", "Token "+c.config.Token)
+	}
+	return base + "/api/v2/write?" + query.Encode(), header
+}
+
+type influxPoint struct {
+	measurement string
+	tags        []seriesLabel
+	fields      []seriesLabel
+}
+
+// formatLineProtocol renders the series as line protocol points, merging the
+// fields of series with the same measurement and tags. Values InfluxDB cannot
+// store (NaN and infinities) are skipped.
+func formatLineProtocol(keys []string, metrics map[string]float64, timestamp time.Time, logger *slog.Logger) string {
+	points := make(map[string]*influxPoint)
+	var order []string
+
+	for _, key := range keys {
+		value := metrics[key]
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			logger.Debug("Skipping series without a finite value", "series", key)
+			continue
+		}
+		name, labels, err := parseSeries(key)
+		if err != nil {
+			logger.Debug("Skipping series", "series", key, "error", err)
+			continue
+		}
+
+		var tags []seriesLabel
+		for _, l := range labels {
+			// Empty tag values are not allowed in line protocol.
+			if l.Value != "" {
+				tags = append(tags, l)
+			}
+		}
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+		measurement := collectorOf(name)
+		id := measurement
+		for _, t := range tags {
+			id += "\x00" + t.Name + "\x00" + t.Value
+		}
+		p, ok := points[id]
+		if !ok {
+			p = &influxPoint{measurement: measurement, tags: tags}
+			points[id] = p
+			order = append(order, id)
+		}
+		p.fields = append(p.fields, seriesLabel{Name: name, Value: strconv.FormatFloat(value, 'g', -1, 64)})
+	}
+	sort.Strings(order)
+
+	var b strings.Builder
+	ts := strconv.FormatInt(timestamp.UnixMilli(), 10)
+	for _, id := range order {
+		p := points[id]
+		// Keys come in map order; sort so a point serialises the same every cycle.
+		sort.Slice(p.fields, func(i, j int) bool { return p.fields[i].Name < p.fields[j].Name })
+		b.WriteString(influxMeasurementEscaper.Replace(p.measurement))
+		for _, t := range p.tags {
+			b.WriteByte(',')
+			b.WriteString(influxKeyEscaper.Replace(t.Name))
+			b.WriteByte('=')
+			b.WriteString(influxKeyEscaper.Replace(t.Value))
+		}
+		for i, f := range p.fields {
+			if i == 0 {
+				b.WriteByte(' ')
+			} else {
+				b.WriteByte(',')
+			}
+			b.WriteString(influxKeyEscaper.Replace(f.Name))
+			b.WriteByte('=')
+			b.WriteString(f.Value)
+		}
+		b.WriteByte(' ')
+		b.WriteString(ts)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+func TestFormatLineProtocol(t *testing.T) {
+	metrics := map[string]float64{
+		`node_info{hostname="web 1",os_name="Debian"}`: 1,
+		`node_cpu_cores`:                                   8,
+		`node_cpu_threads`:                                 16,
+		`disk_used_bytes{mount="/",device=""}`:             42,
+		`log_match_value_bucket{pattern="slow",le="1"}`:    3,
+		`log_match_value_bucket{pattern="slow",le="+Inf"}`: 5,
+		`memory_usage_percent`:                             math.NaN(),
+	}
+	// Fields of a point arrive out of order and are sorted.
+	keys := []string{
+		`node_cpu_threads`,
+		`log_match_value_bucket{pattern="slow",le="1"}`,
+		`node_info{hostname="web 1",os_name="Debian"}`,
+		`memory_usage_percent`,
+		`node_cpu_cores`,
+		`log_match_value_bucket{pattern="slow",le="+Inf"}`,
+		`disk_used_bytes{mount="/",device=""}`,
+	}
+
+	got := formatLineProtocol(keys, metrics, time.UnixMilli(1700000000123), discardLogger())
+	want := "disk,mount=/ disk_used_bytes=42 1700000000123\n" +
+		"inventory node_cpu_cores=8,node_cpu_threads=16 1700000000123\n" +
+		"inventory,hostname=web\\ 1,os_name=Debian node_info=1 1700000000123\n" +
+		"logwatch,le=+Inf,pattern=slow log_match_value_bucket=5 1700000000123\n" +
+		"logwatch,le=1,pattern=slow log_match_value_bucket=3 1700000000123\n"
+	if got != want {
+		t.Errorf("line protocol:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestInfluxClientSend(t *testing.T) {
+	tests := []struct {
+		config   models.InfluxDBConfig
+		path     string
+		query    string
+		authPref string
+	}{
+		{
+			config:   models.InfluxDBConfig{APIVersion: 1, Database: "sysmon", RetentionPolicy: "week", Username: "u", Password: "p"},
+			path:     "/write",
+			query:    "db=sysmon&precision=ms&rp=week",
+			authPref: "Basic ",
+		},
+		{
+			config:   models.InfluxDBConfig{APIVersion: 2, Org: "acme", Bucket: "sysmon", Token: "t0k"},
+			path:     "/api/v2/write",
+			query:    "bucket=sysmon&org=acme&precision=ms",
+			authPref: "Token t0k",
+		},
+	}
+
+	for _, tt := range tests {
+		var body, path, query, auth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/ping" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			body, path, query, auth = string(data), r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		tt.config.URL = server.URL
+		client, err := NewInfluxClient(tt.config, models.DatabaseConfig{}, discardLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Ping(context.Background()); err != nil {
+			t.Errorf("v%d ping: %v", tt.config.APIVersion, err)
+		}
+		if err := client.SendMetrics(context.Background(), map[string]float64{"node_uptime_seconds": 60}, time.UnixMilli(5)); err != nil {
+			t.Errorf("v%d send: %v", tt.config.APIVersion, err)
+		}
+		server.Close()
+
+		if path != tt.path || query != tt.query {
+			t.Errorf("v%d wrote to %s?%s, want %s?%s", tt.config.APIVersion, path, query, tt.path, tt.query)
+		}
+		if len(auth) < len(tt.authPref) || auth[:len(tt.authPref)] != tt.authPref {
+			t.Errorf("v%d authorization %q", tt.config.APIVersion, auth)
+		}
+		if want := "inventory node_uptime_seconds=60 5\n"; body != want {
+			t.Errorf("v%d body %q, want %q", tt.config.APIVersion, body, want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"system-monitoring/models"
+)
+
+var (
+	integrityMonitorOnce sync.Once
+	integrityMonitor     *IntegrityMonitor
+	integrityMonitorErr  error
+)
+
+// fileRecord is the state of one file that is compared between cycles.
+type fileRecord struct {
+	size    int64
+	mode    fs.FileMode
+	uid     uint32
+	gid     uint32
+	modTime time.Time
+	sha256  string // empty when the file is too large or was never readable
+	// unhashable is set when the file could not be read this cycle; sha256
+	// then still holds the last hash that could be taken.
+	unhashable bool
+}
+
+type integrityRoot struct {
+	path      string
+	files     map[string]fileRecord
+	baselined bool // files holds a complete scan
+	stats     models.IntegrityPathStats
+}
+
+// IntegrityMonitor records the metadata and SHA-256 of the configured paths
+// on its first check and reports every later modification, creation or
+// deletion as a structured log event.
+type IntegrityMonitor struct {
+	mu          sync.Mutex
+	roots       []*integrityRoot
+	maxFiles    int
+	maxHashSize int64
+	logger      *slog.Logger
+}
+
+func NewIntegrityMonitor(config models.IntegrityConfig, logger *slog.Logger) (*IntegrityMonitor, error) {
+	m := &IntegrityMonitor{
+		maxFiles:    config.MaxFiles,
+		maxHashSize: config.MaxHashSize,
+		logger:      logger,
+	}
+	for _, path := range config.Paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		m.roots = append(m.roots, &integrityRoot{
+			path:  path,
+			stats: models.IntegrityPathStats{Path: path},
+		})
+	}
+	if len(m.roots) == 0 {
+		return nil, fmt.Errorf("no integrity paths configured")
+	}
+	return m, nil
+}
+
+// GetIntegrityStats checks the process-wide integrity monitor, creating it
+// from config on first use.
+func GetIntegrityStats(config models.IntegrityConfig, logger *slog.Logger) (*models.IntegrityStats, error) {
+	integrityMonitorOnce.Do(func() {
+		integrityMonitor, integrityMonitorErr = NewIntegrityMonitor(config, logger)
+	})
+	if integrityMonitorErr != nil {
+		return nil, integrityMonitorErr
+	}
+	return integrityMonitor.Check()
+}
+
+func (m *IntegrityMonitor) Check() (*models.IntegrityStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	stats := &models.IntegrityStats{Timestamp: now}
+
+	for _, root := range m.roots {
+		files, err := m.scan(root.path, root.files)
+		if err != nil {
+			// A partial scan would report every file it missed as deleted;
+			// keep comparing against the last complete one instead.
+			m.logger.Warn("Failed to scan integrity path", "path", root.path, "error", err)
+			stats.Paths = append(stats.Paths, root.stats)
+			continue
+		}
+
+		if root.baselined {
+			m.compare(root, files, now)
+		}
+		root.files = files
+		root.baselined = true
+		root.stats.WatchedFiles = len(files)
+		root.stats.UnhashableFiles = 0
+		for _, f := range files {
+			if f.unhashable {
+				root.stats.UnhashableFiles++
+			}
+		}
+		stats.Paths = append(stats.Paths, root.stats)
+	}
+
+	return stats, nil
+}
+
+func (m *IntegrityMonitor) compare(root *integrityRoot, files map[string]fileRecord, now time.Time) {
+	paths := make([]string, 0, len(files)+len(root.files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	for path := range root.files {
+		if _, ok := files[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		before, existed := root.files[path]
+		after, exists := files[path]
+
+		var change string
+		var fields []string
+		switch {
+		case !existed:
+			change = "created"
+		case !exists:
+			change = "deleted"
+		default:
+			fields = changedFields(before, after)
+			if len(fields) == 0 {
+				continue
+			}
+			change = "modified"
+		}
+
+		root.stats.Changes++
+		root.stats.LastChangeTime = now
+		m.logger.Warn("File integrity change",
+			"path", path,
+			"watched_path", root.path,
+			"change", change,
+			"fields", fields,
+			"old_sha256", before.sha256,
+			"new_sha256", after.sha256,
+		)
+	}
+}
+
+func changedFields(before, after fileRecord) []string {
+	var fields []string
+	if before.size != after.size {
+		fields = append(fields, "size")
+	}
+	if before.mode != after.mode {
+		fields = append(fields, "mode")
+	}
+	if before.uid != after.uid || before.gid != after.gid {
+		fields = append(fields, "owner")
+	}
+	if !before.modTime.Equal(after.modTime) {
+		fields = append(fields, "mtime")
+	}
+	if before.sha256 != after.sha256 {
+		fields = append(fields, "sha256")
+	}
+	return fields
+}
+
+// scan records every regular file below path, or path itself when it is a
+// file. Symlinks are recorded but not followed. A file that cannot be hashed
+// keeps its previous hash, so that a content change is still reported once it
+// is readable again.
+func (m *IntegrityMonitor) scan(path string, previous map[string]fileRecord) (map[string]fileRecord, error) {
+	files := make(map[string]fileRecord)
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == path {
+				if errors.Is(err, fs.ErrNotExist) {
+					// Removing a watched path reports its files as deleted.
+					return nil
+				}
+				return err
+			}
+			m.logger.Debug("Skipping unreadable path", "path", p, "error", err)
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if len(files) >= m.maxFiles {
+			return fmt.Errorf("more than %d files, stopped scanning", m.maxFiles)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		record := fileRecord{
+			size:    info.Size(),
+			mode:    info.Mode(),
+			modTime: info.ModTime(),
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			record.uid = st.Uid
+			record.gid = st.Gid
+		}
+		if info.Mode().IsRegular() && info.Size() <= m.maxHashSize {
+			sum, err := hashFile(p)
+			if err != nil {
+				before := previous[p]
+				if !before.unhashable {
+					m.logger.Warn("Failed to hash watched file", "path", p, "error", err)
+				}
+				sum = before.sha256
+				record.unhashable = true
+			}
+			record.sha256 = sum
+		}
+		files[p] = record
+		return nil
+	})
+
+	return files, err
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
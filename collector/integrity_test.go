@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+func newTestIntegrityMonitor(t *testing.T, dir string) *IntegrityMonitor {
+	t.Helper()
+	m, err := NewIntegrityMonitor(models.IntegrityConfig{
+		Paths:       []string{dir},
+		MaxFiles:    100,
+		MaxHashSize: 1 << 20,
+	}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func checkIntegrity(t *testing.T, m *IntegrityMonitor) models.IntegrityPathStats {
+	t.Helper()
+	stats, err := m.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats.Paths[0]
+}
+
+// rewrite replaces the content of path without changing its size or mtime.
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), info.Mode()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIntegrityMonitorDetectsChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"passwd": "root:x:0:0\n", "sub/hosts": "127.0.0.1 localhost\n"})
+	m := newTestIntegrityMonitor(t, dir)
+
+	if got := checkIntegrity(t, m); got.WatchedFiles != 2 || got.Changes != 0 {
+		t.Fatalf("baseline = %+v", got)
+	}
+
+	rewrite(t, filepath.Join(dir, "passwd"), "evil:x:0:0\n")
+	if got := checkIntegrity(t, m); got.Changes != 1 {
+		t.Errorf("changes after a same-size rewrite = %d, want 1", got.Changes)
+	}
+
+	os.Remove(filepath.Join(dir, "sub/hosts"))
+	writeTree(t, dir, map[string]string{"shadow": "root:*:1:0\n"})
+	got := checkIntegrity(t, m)
+	if got.Changes != 3 || got.WatchedFiles != 2 {
+		t.Errorf("after delete and create = %+v, want 3 changes and 2 files", got)
+	}
+	if time.Since(got.LastChangeTime) > time.Minute {
+		t.Errorf("last change time = %v", got.LastChangeTime)
+	}
+}
+
+func TestIntegrityMonitorUnreadableFile(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read files without permission")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret")
+	writeTree(t, dir, map[string]string{"secret": "original\n"})
+	m := newTestIntegrityMonitor(t, dir)
+	checkIntegrity(t, m)
+	baseline := m.roots[0].files[path]
+
+	// Change the content while the file is unreadable, then make it readable
+	// again: the change has to be found against the last hash taken.
+	rewrite(t, path, "modified\n")
+	os.Chmod(path, 0)
+	if got := checkIntegrity(t, m); got.UnhashableFiles != 1 {
+		t.Errorf("unhashable files = %d, want 1", got.UnhashableFiles)
+	}
+	unreadable := m.roots[0].files[path]
+	if !unreadable.unhashable || unreadable.sha256 != baseline.sha256 {
+		t.Fatalf("unreadable record = %+v, want the baseline hash kept", unreadable)
+	}
+
+	os.Chmod(path, 0o644)
+	if got := checkIntegrity(t, m); got.UnhashableFiles != 0 {
+		t.Errorf("unhashable files = %d, want 0", got.UnhashableFiles)
+	}
+	fields := changedFields(unreadable, m.roots[0].files[path])
+	if !slices.Contains(fields, "sha256") {
+		t.Errorf("changed fields = %v, want sha256 among them", fields)
+	}
+}
+
+func TestIntegrityMonitorIncompleteScan(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"b": "1\n", "c": "2\n"})
+	m, err := NewIntegrityMonitor(models.IntegrityConfig{Paths: []string{dir}, MaxFiles: 2, MaxHashSize: 1 << 20}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := checkIntegrity(t, m); got.WatchedFiles != 2 {
+		t.Fatalf("baseline = %+v", got)
+	}
+
+	// "a" is walked first and pushes "c" past the limit; nothing is reported
+	// deleted and the baseline is kept.
+	writeTree(t, dir, map[string]string{"a": "3\n"})
+	if got := checkIntegrity(t, m); got.Changes != 0 || got.WatchedFiles != 2 {
+		t.Errorf("after hitting the limit = %+v, want no changes and 2 files", got)
+	}
+	if len(m.roots[0].files) != 2 {
+		t.Errorf("records = %v, want the previous scan", m.roots[0].files)
+	}
+
+	// Once the scan completes again, it is compared with the last complete one.
+	os.Remove(filepath.Join(dir, "b"))
+	got := checkIntegrity(t, m)
+	if got.Changes != 2 {
+		t.Errorf("changes = %d, want a created and b deleted", got.Changes)
+	}
+
+	// A watched path that disappears reports its files deleted.
+	os.RemoveAll(dir)
+	if got := checkIntegrity(t, m); got.Changes != 4 || got.WatchedFiles != 0 {
+		t.Errorf("after removing the path = %+v, want 4 changes and 0 files", got)
+	}
+}
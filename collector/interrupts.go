@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+type interruptRow struct {
+	name   string
+	info   string
+	counts []uint64
+}
+
+func GetInterruptStats(procPath, aggregation string) (*models.InterruptsStats, error) {
+	switch aggregation {
+	case "none", "cpu", "irq":
+	default:
+		return nil, fmt.Errorf("unknown interrupt aggregation %q", aggregation)
+	}
+
+	data, err := os.ReadFile(filepath.Join(procPath, "interrupts"))
+	if err != nil {
+		return nil, err
+	}
+	cpus, rows := parseInterruptTable(string(data))
+	interrupts := aggregateInterrupts(cpus, rows, aggregation)
+
+	data, err = os.ReadFile(filepath.Join(procPath, "softirqs"))
+	if err != nil {
+		return nil, err
+	}
+	cpus, rows = parseInterruptTable(string(data))
+	softirqs := aggregateInterrupts(cpus, rows, aggregation)
+
+	return &models.InterruptsStats{
+		Interrupts: interrupts,
+		Softirqs:   softirqs,
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// parseInterruptTable parses the layout shared by /proc/interrupts and
+// /proc/softirqs: a header naming the CPUs, then one row per source with a
+// count per CPU, optionally followed by a description of the source.
+func parseInterruptTable(data string) ([]string, []interruptRow) {
+	lines := strings.Split(data, "\n")
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	cpus := strings.Fields(lines[0])
+	var rows []interruptRow
+
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+
+		row := interruptRow{name: strings.TrimSuffix(fields[0], ":")}
+		i := 1
+		for ; i < len(fields) && len(row.counts) < len(cpus); i++ {
+			count, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				break
+			}
+			row.counts = append(row.counts, count)
+		}
+		row.info = strings.Join(fields[i:], " ")
+		rows = append(rows, row)
+	}
+
+	return cpus, rows
+}
+
+// aggregateInterrupts flattens the table into counters. Rows that do not have
+// a count per CPU, such as ERR and MIS, are always reported as one total.
+func aggregateInterrupts(cpus []string, rows []interruptRow, aggregation string) []models.InterruptCounter {
+	var counters []models.InterruptCounter
+	perCPU := make([]uint64, len(cpus))
+
+	for _, row := range rows {
+		if len(row.counts) != len(cpus) || aggregation == "cpu" {
+			var total uint64
+			for _, c := range row.counts {
+				total += c
+			}
+			counters = append(counters, models.InterruptCounter{IRQ: row.name, Info: row.info, Count: total})
+			continue
+		}
+
+		for i, c := range row.counts {
+			if aggregation == "irq" {
+				perCPU[i] += c
+				continue
+			}
+			counters = append(counters, models.InterruptCounter{IRQ: row.name, Info: row.info, CPU: cpus[i], Count: c})
+		}
+	}
+
+	if aggregation == "irq" {
+		for i, cpu := range cpus {
+			counters = append(counters, models.InterruptCounter{CPU: cpu, Count: perCPU[i]})
+		}
+	}
+
+	return counters
+}
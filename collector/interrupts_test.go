@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"slices"
+	"testing"
+
+	"system-monitoring/models"
+)
+
+const interruptsFixture = `           CPU0       CPU1       
+  0:         35          0   IO-APIC   2-edge      timer
+  8:          0          1   IO-APIC   8-edge      rtc0
+ 24:     123456      78901   PCI-MSI 327680-edge      xhci_hcd
+NMI:          5          7   Non-maskable interrupts
+LOC:    1000000     900000   Local timer interrupts
+ERR:          0
+MIS:          3
+`
+
+const softirqsFixture = `                    CPU0       CPU1       
+          HI:          1          0
+       TIMER:     500000     400000
+      NET_RX:       1000       2000
+`
+
+func TestGetInterruptStatsFixture(t *testing.T) {
+	proc := t.TempDir()
+	writeTree(t, proc, map[string]string{"interrupts": interruptsFixture, "softirqs": softirqsFixture})
+
+	tests := []struct {
+		aggregation string
+		interrupts  []models.InterruptCounter
+		softirqs    []models.InterruptCounter
+	}{
+		{
+			aggregation: "cpu",
+			interrupts: []models.InterruptCounter{
+				{IRQ: "0", Info: "IO-APIC 2-edge timer", Count: 35},
+				{IRQ: "8", Info: "IO-APIC 8-edge rtc0", Count: 1},
+				{IRQ: "24", Info: "PCI-MSI 327680-edge xhci_hcd", Count: 202357},
+				{IRQ: "NMI", Info: "Non-maskable interrupts", Count: 12},
+				{IRQ: "LOC", Info: "Local timer interrupts", Count: 1900000},
+				{IRQ: "ERR", Count: 0},
+				{IRQ: "MIS", Count: 3},
+			},
+			softirqs: []models.InterruptCounter{
+				{IRQ: "HI", Count: 1},
+				{IRQ: "TIMER", Count: 900000},
+				{IRQ: "NET_RX", Count: 3000},
+			},
+		},
+		{
+			aggregation: "irq",
+			// Rows without a count per CPU stay totals.
+			interrupts: []models.InterruptCounter{
+				{IRQ: "ERR", Count: 0},
+				{IRQ: "MIS", Count: 3},
+				{CPU: "CPU0", Count: 1123496},
+				{CPU: "CPU1", Count: 978909},
+			},
+			softirqs: []models.InterruptCounter{
+				{CPU: "CPU0", Count: 501001},
+				{CPU: "CPU1", Count: 402000},
+			},
+		},
+		{
+			aggregation: "none",
+			softirqs: []models.InterruptCounter{
+				{IRQ: "HI", CPU: "CPU0", Count: 1},
+				{IRQ: "HI", CPU: "CPU1", Count: 0},
+				{IRQ: "TIMER", CPU: "CPU0", Count: 500000},
+				{IRQ: "TIMER", CPU: "CPU1", Count: 400000},
+				{IRQ: "NET_RX", CPU: "CPU0", Count: 1000},
+				{IRQ: "NET_RX", CPU: "CPU1", Count: 2000},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		stats, err := GetInterruptStats(proc, tt.aggregation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.interrupts != nil && !slices.Equal(stats.Interrupts, tt.interrupts) {
+			t.Errorf("%s: interrupts\n%+v\nwant\n%+v", tt.aggregation, stats.Interrupts, tt.interrupts)
+		}
+		if !slices.Equal(stats.Softirqs, tt.softirqs) {
+			t.Errorf("%s: softirqs\n%+v\nwant\n%+v", tt.aggregation, stats.Softirqs, tt.softirqs)
+		}
+		if tt.aggregation == "none" {
+			// Five rows with two CPUs each, plus ERR and MIS.
+			if len(stats.Interrupts) != 12 || stats.Interrupts[5] != (models.InterruptCounter{IRQ: "24", Info: "PCI-MSI 327680-edge xhci_hcd", CPU: "CPU1", Count: 78901}) {
+				t.Errorf("none: interrupts = %+v", stats.Interrupts)
+			}
+		}
+	}
+
+	if _, err := GetInterruptStats(proc, "socket"); err == nil {
+		t.Error("expected an error for an unknown aggregation")
+	}
+}
@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"system-monitoring/models"
+)
+
+func GetInventoryStats(procPath, sysPath string) (*models.InventoryStats, error) {
+	now := time.Now()
+	stats := &models.InventoryStats{Timestamp: now}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	stats.Hostname = hostname
+
+	var uts syscall.Utsname
+	if err := syscall.Uname(&uts); err == nil {
+		stats.KernelRelease = utsnameString(uts.Release[:])
+	}
+
+	osRelease := readOSRelease()
+	stats.OSName = osRelease["NAME"]
+	stats.OSVersion = osRelease["VERSION_ID"]
+	if stats.OSVersion == "" {
+		stats.OSVersion = osRelease["VERSION"]
+	}
+
+	if err := readCPUInfo(filepath.Join(procPath, "cpuinfo"), stats); err != nil {
+		return nil, err
+	}
+
+	dmiPath := filepath.Join(sysPath, "class", "dmi", "id")
+	stats.SystemVendor = readSysfsString(filepath.Join(dmiPath, "sys_vendor"))
+	stats.ProductName = readSysfsString(filepath.Join(dmiPath, "product_name"))
+	if stats.ProductName == "" {
+		// Boards without DMI, such as the Raspberry Pi, name themselves in the device tree.
+		stats.ProductName = readSysfsString(filepath.Join(procPath, "device-tree", "model"))
+	}
+
+	data, err := os.ReadFile(filepath.Join(procPath, "uptime"))
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) > 0 {
+		seconds, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		stats.Uptime = time.Duration(seconds * float64(time.Second))
+		stats.BootTime = now.Add(-stats.Uptime)
+	}
+
+	return stats, nil
+}
+
+// readCPUInfo fills the CPU model and core/thread counts. Cores are counted as
+// distinct (physical id, core id) pairs; when the kernel does not report
+// topology, as on most ARM boards, every thread is treated as a core.
+func readCPUInfo(path string, stats *models.InventoryStats) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	cores := make(map[string]bool)
+	var physicalID, hardware string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "processor":
+			stats.CPUThreads++
+		case "model name":
+			if stats.CPUModel == "" {
+				stats.CPUModel = value
+			}
+		case "Hardware":
+			hardware = value
+		case "physical id":
+			physicalID = value
+		case "core id":
+			cores[physicalID+"/"+value] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if stats.CPUModel == "" {
+		stats.CPUModel = hardware
+	}
+	stats.CPUCores = len(cores)
+	if stats.CPUCores == 0 {
+		stats.CPUCores = stats.CPUThreads
+	}
+	return nil
+}
+
+func readOSRelease() map[string]string {
+	values := make(map[string]string)
+	for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			key, value, ok := strings.Cut(line, "=")
+			if !ok || strings.HasPrefix(strings.TrimSpace(line), "#") {
+				continue
+			}
+			values[key] = strings.Trim(value, `"'`)
+		}
+		break
+	}
+	return values
+}
+
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(data), "\x00"))
+}
+
+func utsnameString[T int8 | uint8](field []T) string {
+	var b strings.Builder
+	for _, c := range field {
+		if c == 0 {
+			break
+		}
+		b.WriteByte(byte(c))
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+const x86CPUInfo = `processor	: 0
+model name	: Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz
+physical id	: 0
+core id		: 0
+
+processor	: 1
+model name	: Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz
+physical id	: 0
+core id		: 1
+
+processor	: 2
+model name	: Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz
+physical id	: 0
+core id		: 0
+
+processor	: 3
+model name	: Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz
+physical id	: 1
+core id		: 0
+`
+
+const armCPUInfo = `processor	: 0
+BogoMIPS	: 108.00
+Features	: fp asimd evtstrm crc32 cpuid
+
+processor	: 1
+BogoMIPS	: 108.00
+Features	: fp asimd evtstrm crc32 cpuid
+
+Hardware	: BCM2835
+Revision	: c03111
+`
+
+func TestGetInventoryStatsFixture(t *testing.T) {
+	tests := []struct {
+		name    string
+		proc    map[string]string
+		sys     map[string]string
+		model   string
+		cores   int
+		threads int
+		vendor  string
+		product string
+	}{
+		{
+			// Two hyper-threads share core 0 of socket 0; socket 1 has its own core 0.
+			name:    "x86",
+			proc:    map[string]string{"cpuinfo": x86CPUInfo, "uptime": "3600.50 7000.00\n"},
+			sys:     map[string]string{"class/dmi/id/sys_vendor": "Dell Inc.\n", "class/dmi/id/product_name": "PowerEdge R630\n"},
+			model:   "Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz",
+			cores:   3,
+			threads: 4,
+			vendor:  "Dell Inc.",
+			product: "PowerEdge R630",
+		},
+		{
+			name:    "arm without topology or DMI",
+			proc:    map[string]string{"cpuinfo": armCPUInfo, "uptime": "3600.50 7000.00\n", "device-tree/model": "Raspberry Pi 4 Model B Rev 1.1\x00"},
+			model:   "BCM2835",
+			cores:   2,
+			threads: 2,
+			product: "Raspberry Pi 4 Model B Rev 1.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc, sys := t.TempDir(), t.TempDir()
+			writeTree(t, proc, tt.proc)
+			writeTree(t, sys, tt.sys)
+
+			stats, err := GetInventoryStats(proc, sys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.CPUModel != tt.model || stats.CPUCores != tt.cores || stats.CPUThreads != tt.threads {
+				t.Errorf("cpu = %q, %d cores, %d threads", stats.CPUModel, stats.CPUCores, stats.CPUThreads)
+			}
+			if stats.SystemVendor != tt.vendor || stats.ProductName != tt.product {
+				t.Errorf("system = %q %q", stats.SystemVendor, stats.ProductName)
+			}
+			if stats.Uptime != 3600500*time.Millisecond {
+				t.Errorf("uptime = %v", stats.Uptime)
+			}
+			if got := stats.Timestamp.Sub(stats.BootTime); got != stats.Uptime {
+				t.Errorf("boot time is %v before the timestamp, want the uptime", got)
+			}
+			if stats.Hostname == "" {
+				t.Error("no hostname")
+			}
+		})
+	}
+}
+
+func TestGetInventoryStatsMissingUptime(t *testing.T) {
+	proc := t.TempDir()
+	writeTree(t, proc, map[string]string{"cpuinfo": armCPUInfo})
+	if _, err := GetInventoryStats(proc, t.TempDir()); err == nil {
+		t.Error("expected an error without /proc/uptime")
+	}
+}
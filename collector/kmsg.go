@@ -0,0 +1,220 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"system-monitoring/models"
+)
+
+var kmsgSeverities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+var kmsgClasses = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"oom_kill", regexp.MustCompile(`(?i)out of memory: kill`)},
+	{"segfault", regexp.MustCompile(`segfault at`)},
+	{"hung_task", regexp.MustCompile(`blocked for more than \d+ seconds`)},
+	{"io_error", regexp.MustCompile(`I/O error`)},
+	{"mce", regexp.MustCompile(`(?i)machine check|mce: |\[Hardware Error\]`)},
+}
+
+var (
+	kmsgReaderOnce sync.Once
+	kmsgReader     *KmsgReader
+)
+
+// KmsgReader counts kernel log records by severity and by recognised event
+// class. Counters are cumulative for the lifetime of the process.
+type KmsgReader struct {
+	mu         sync.Mutex
+	path       string
+	forward    bool
+	fd         int
+	partial    []byte
+	bySeverity map[string]int64
+	byClass    map[string]int64
+	logger     *slog.Logger
+}
+
+func NewKmsgReader(config models.KmsgConfig, logger *slog.Logger) *KmsgReader {
+	r := &KmsgReader{
+		path:       config.Path,
+		forward:    config.Forward,
+		fd:         -1,
+		bySeverity: make(map[string]int64),
+		byClass:    make(map[string]int64),
+		logger:     logger,
+	}
+	for _, s := range kmsgSeverities {
+		r.bySeverity[s] = 0
+	}
+	for _, c := range kmsgClasses {
+		r.byClass[c.name] = 0
+	}
+	return r
+}
+
+// GetKernelEventStats polls the process-wide kmsg reader, creating it from
+// config on first use.
+func GetKernelEventStats(config models.KmsgConfig, logger *slog.Logger) (*models.KernelEventStats, error) {
+	kmsgReaderOnce.Do(func() {
+		kmsgReader = NewKmsgReader(config, logger)
+	})
+	return kmsgReader.Collect()
+}
+
+// Collect consumes the records written since the previous call. The kmsg
+// device is opened on first use and positioned at its end, so the boot backlog
+// is not counted; a plain file is read from the start.
+func (r *KmsgReader) Collect() (*models.KernelEventStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fd < 0 {
+		fd, err := r.open()
+		if err != nil {
+			return nil, err
+		}
+		r.fd = fd
+	}
+
+	if err := r.read(); err != nil {
+		return nil, err
+	}
+
+	stats := &models.KernelEventStats{
+		BySeverity: make(map[string]int64, len(r.bySeverity)),
+		ByClass:    make(map[string]int64, len(r.byClass)),
+		Timestamp:  time.Now(),
+	}
+	for k, v := range r.bySeverity {
+		stats.BySeverity[k] = v
+	}
+	for k, v := range r.byClass {
+		stats.ByClass[k] = v
+	}
+	return stats, nil
+}
+
+func (r *KmsgReader) open() (int, error) {
+	fd, err := syscall.Open(r.path, syscall.O_RDONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, fmt.Errorf("opening %s: %w", r.path, err)
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		syscall.Close(fd)
+		return -1, fmt.Errorf("opening %s: %w", r.path, err)
+	}
+	if st.Mode&syscall.S_IFMT == syscall.S_IFCHR {
+		if _, err := syscall.Seek(fd, 0, io.SeekEnd); err != nil {
+			syscall.Close(fd)
+			return -1, fmt.Errorf("seeking %s: %w", r.path, err)
+		}
+	}
+	return fd, nil
+}
+
+// read drains the descriptor. The kmsg device returns one record per read and
+// EAGAIN once caught up; a plain file returns arbitrary chunks and 0 at EOF.
+func (r *KmsgReader) read() error {
+	buf := make([]byte, 8192)
+	for {
+		n, err := syscall.Read(r.fd, buf)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EPIPE {
+			// The ring buffer wrapped and records were lost; reading continues
+			// from the oldest record still available.
+			r.logger.Warn("Kernel log records were overwritten before being read")
+			continue
+		}
+		if err == syscall.EAGAIN {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", r.path, err)
+		}
+		if n == 0 {
+			return nil
+		}
+
+		data := append(r.partial, buf[:n]...)
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			r.handle(string(data[:i]))
+			data = data[i+1:]
+		}
+		r.partial = append([]byte(nil), data...)
+	}
+}
+
+// handle processes one "prio,seq,usec,flags;message" record. Continuation
+// lines, which start with a space, carry key=value metadata and are skipped.
+func (r *KmsgReader) handle(line string) {
+	if line == "" || line[0] == ' ' {
+		return
+	}
+
+	header, message, ok := strings.Cut(line, ";")
+	if !ok {
+		return
+	}
+	fields := strings.Split(header, ",")
+	prio, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return
+	}
+
+	severity := kmsgSeverities[prio&7]
+	r.bySeverity[severity]++
+
+	class := ""
+	for _, c := range kmsgClasses {
+		if c.re.MatchString(message) {
+			class = c.name
+			r.byClass[class]++
+			break
+		}
+	}
+
+	if r.forward {
+		attrs := []any{"severity", severity, "facility", prio >> 3}
+		if len(fields) > 1 {
+			attrs = append(attrs, "seq", fields[1])
+		}
+		if class != "" {
+			attrs = append(attrs, "class", class)
+		}
+		r.logger.Log(context.Background(), kmsgLogLevel(prio&7), message, attrs...)
+	}
+}
+
+func kmsgLogLevel(level int) slog.Level {
+	switch {
+	case level <= 3:
+		return slog.LevelError
+	case level == 4:
+		return slog.LevelWarn
+	case level == 7:
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"system-monitoring/models"
+)
+
+func TestKmsgReaderFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kmsg")
+	records := "3,100,5000,-;Out of memory: Killed process 1234 (java)\n" +
+		" SUBSYSTEM=memory\n" +
+		"6,101,5001,-;app[42]: segfault at 0 ip 000055d0 sp 00007ffc error 4\n" +
+		"4,102,5002,-;INFO: task kworker:1 blocked for more than 120 seconds.\n"
+	if err := os.WriteFile(path, []byte(records), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewKmsgReader(models.KmsgConfig{Path: path}, discardLogger())
+	stats, err := r.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSeverity := map[string]int64{"err": 1, "info": 1, "warning": 1, "debug": 0}
+	for k, want := range wantSeverity {
+		if got := stats.BySeverity[k]; got != want {
+			t.Errorf("severity %s = %d, want %d", k, got, want)
+		}
+	}
+	wantClass := map[string]int64{"oom_kill": 1, "segfault": 1, "hung_task": 1, "io_error": 0}
+	for k, want := range wantClass {
+		if got := stats.ByClass[k]; got != want {
+			t.Errorf("class %s = %d, want %d", k, got, want)
+		}
+	}
+
+	// Appended records, including one split across writes, are picked up by
+	// the next poll.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString("3,103,5003,-;blk_update_request: I/O err")
+	if _, err := r.Collect(); err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("or, dev sda, sector 42\n")
+	stats, err = r.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stats.ByClass["io_error"]; got != 1 {
+		t.Errorf("class io_error = %d, want 1", got)
+	}
+	if got := stats.BySeverity["err"]; got != 2 {
+		t.Errorf("severity err = %d, want 2", got)
+	}
+}
@@ -0,0 +1,231 @@
+package collector
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+const (
+	tcpStateListen = "0A"
+	tcpStateClose  = "07" // bound UDP sockets without a peer
+)
+
+type listenerKey struct {
+	protocol string
+	address  string
+	port     int
+}
+
+// listenerState remembers the listeners of the previous cycle so that opened
+// and closed ones can be reported.
+var listenerState = struct {
+	sync.Mutex
+	seen   map[listenerKey]models.ListenerStats
+	opened uint64
+	closed uint64
+}{}
+
+func GetListenerStats(procPath string, logger *slog.Logger) (*models.ListenersStats, error) {
+	var listeners []models.ListenerStats
+	inodes := make(map[string]int)
+
+	for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		entries, err := readSocketTable(filepath.Join(procPath, "net", protocol), protocol)
+		if os.IsNotExist(err) {
+			// IPv6 disabled
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			inodes[e.inode] = len(listeners)
+			listeners = append(listeners, e.ListenerStats)
+		}
+	}
+
+	resolveSocketOwners(procPath, inodes, listeners)
+	listeners = mergeListeners(listeners)
+
+	sort.Slice(listeners, func(i, j int) bool {
+		a, b := listeners[i], listeners[j]
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Address < b.Address
+	})
+
+	listenerState.Lock()
+	defer listenerState.Unlock()
+
+	current := make(map[listenerKey]models.ListenerStats, len(listeners))
+	for _, l := range listeners {
+		key := listenerKey{l.Protocol, l.Address, l.Port}
+		current[key] = l
+		// The first cycle only records the listeners that already exist.
+		if listenerState.seen == nil {
+			continue
+		}
+		if _, ok := listenerState.seen[key]; !ok {
+			listenerState.opened++
+			logger.Warn("New listening socket", "protocol", l.Protocol, "address", l.Address, "port", l.Port, "process", l.Process, "pid", l.PID)
+		}
+	}
+	for key, l := range listenerState.seen {
+		if _, ok := current[key]; !ok {
+			listenerState.closed++
+			logger.Info("Listening socket closed", "protocol", l.Protocol, "address", l.Address, "port", l.Port, "process", l.Process)
+		}
+	}
+	listenerState.seen = current
+
+	return &models.ListenersStats{
+		Listeners: listeners,
+		Opened:    listenerState.opened,
+		Closed:    listenerState.closed,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+type socketEntry struct {
+	models.ListenerStats
+	inode string
+}
+
+// readSocketTable returns the listening TCP sockets, or the UDP sockets acting
+// as servers, of one /proc/net table. A UDP socket counts when it has no peer;
+// connected ones are clients. Unconnected client sockets, such as those of
+// some resolvers, are reported too, since they cannot be told apart from a
+// server bound to a high port.
+func readSocketTable(path, protocol string) ([]socketEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	udp := strings.HasPrefix(protocol, "udp")
+	wantState := tcpStateListen
+	if udp {
+		wantState = tcpStateClose
+	}
+
+	var entries []socketEntry
+	for _, line := range strings.Split(string(data), "\n")[1:] {
+		// sl local_address rem_address st tx:rx tr:when retrnsmt uid timeout inode
+		fields := strings.Fields(line)
+		if len(fields) < 10 || fields[3] != wantState {
+			continue
+		}
+
+		address, port, err := parseSocketAddress(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if udp && strings.Trim(fields[2], "0:") != "" {
+			continue
+		}
+		entries = append(entries, socketEntry{
+			ListenerStats: models.ListenerStats{Protocol: protocol, Address: address, Port: port},
+			inode:         fields[9],
+		})
+	}
+	return entries, nil
+}
+
+// mergeListeners folds sockets sharing a protocol, address and port, as
+// SO_REUSEPORT groups do, into one listener, keeping an owner if any is known.
+func mergeListeners(listeners []models.ListenerStats) []models.ListenerStats {
+	index := make(map[listenerKey]int, len(listeners))
+	merged := listeners[:0]
+	for _, l := range listeners {
+		key := listenerKey{l.Protocol, l.Address, l.Port}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, l)
+			continue
+		}
+		if merged[i].PID == 0 {
+			merged[i].PID, merged[i].Process = l.PID, l.Process
+		}
+	}
+	return merged
+}
+
+// parseSocketAddress decodes "0100007F:0035". The address is printed as
+// native-endian 32-bit words, little-endian on every platform we run on.
+func parseSocketAddress(s string) (string, int, error) {
+	hexAddr, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("malformed socket address %q", s)
+	}
+
+	raw, err := hex.DecodeString(hexAddr)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("malformed socket address %q", s)
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed socket port %q", s)
+	}
+	return net.IP(raw).String(), int(port), nil
+}
+
+// resolveSocketOwners walks /proc/[pid]/fd to find the process holding each
+// socket inode. Processes we may not inspect are skipped, leaving their
+// listeners without an owner.
+func resolveSocketOwners(procPath string, inodes map[string]int, listeners []models.ListenerStats) {
+	if len(inodes) == 0 {
+		return
+	}
+
+	procs, err := os.ReadDir(procPath)
+	if err != nil {
+		return
+	}
+
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join(procPath, proc.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")
+			i, ok := inodes[inode]
+			if !ok || listeners[i].PID != 0 {
+				continue
+			}
+			listeners[i].PID = pid
+			listeners[i].Process = readSysfsString(filepath.Join(procPath, proc.Name(), "comm"))
+		}
+	}
+}
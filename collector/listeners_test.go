@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"testing"
+
+	"system-monitoring/models"
+)
+
+const socketTableHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+func TestGetListenerStatsFixture(t *testing.T) {
+	proc := t.TempDir()
+	writeTree(t, proc, map[string]string{
+		"net/tcp": socketTableHeader +
+			// 0.0.0.0:22 listening twice with SO_REUSEPORT
+			"   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 101 1\n" +
+			"   1: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 102 1\n" +
+			// an established connection
+			"   2: 0100007F:0016 0100007F:A1B2 01 00000000:00000000 00:00000000 00000000     0        0 103 1\n",
+		"net/udp": socketTableHeader +
+			// 127.0.0.53:53, a server
+			"   0: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 201 2\n" +
+			// an unexpected server on a high port
+			"   1: 00000000:9C40 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 202 2\n" +
+			// a connected client socket
+			"   2: 0A00000A:0FA0 08080808:0035 07 00000000:00000000 00:00000000 00000000     0        0 203 2\n",
+	})
+
+	stats, err := GetListenerStats(proc, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.ListenerStats{
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 22},
+		{Protocol: "udp", Address: "127.0.0.53", Port: 53},
+		{Protocol: "udp", Address: "0.0.0.0", Port: 40000},
+	}
+	if len(stats.Listeners) != len(want) {
+		t.Fatalf("listeners = %+v, want %+v", stats.Listeners, want)
+	}
+	for i := range want {
+		if stats.Listeners[i] != want[i] {
+			t.Errorf("listener %d = %+v, want %+v", i, stats.Listeners[i], want[i])
+		}
+	}
+}
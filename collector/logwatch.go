@@ -0,0 +1,321 @@
+package collector
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// maxLogLineLength bounds how much of an unterminated line is buffered before
+// it is matched as-is.
+const maxLogLineLength = 64 * 1024
+
+// logFingerprintSize is how many bytes before the read offset are kept to
+// recognise a file that was truncated and rewritten past the offset.
+const logFingerprintSize = 64
+
+var (
+	logWatcherOnce sync.Once
+	logWatcher     *LogWatcher
+	logWatcherErr  error
+)
+
+type logPattern struct {
+	name  string
+	re    *regexp.Regexp
+	value int // index of the "value" capture group, -1 when absent
+}
+
+// tailedFile follows a single log file across rotation and truncation.
+type tailedFile struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+	tail    []byte // the bytes just before offset
+	polled  bool
+}
+
+// LogWatcher tails the configured files and counts lines matching each
+// pattern. Counters are cumulative for the lifetime of the process.
+type LogWatcher struct {
+	mu       sync.Mutex
+	files    []*tailedFile
+	patterns []logPattern
+	stats    map[string][]*models.LogPatternStats
+	logger   *slog.Logger
+}
+
+func NewLogWatcher(config models.LogWatchConfig, logger *slog.Logger) (*LogWatcher, error) {
+	if len(config.Files) == 0 {
+		return nil, fmt.Errorf("no log files configured")
+	}
+	if len(config.Patterns) == 0 {
+		return nil, fmt.Errorf("no log patterns configured")
+	}
+
+	names := make([]string, 0, len(config.Patterns))
+	for name := range config.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buckets := append([]float64(nil), config.Buckets...)
+	sort.Float64s(buckets)
+
+	w := &LogWatcher{
+		stats:  make(map[string][]*models.LogPatternStats),
+		logger: logger,
+	}
+
+	for _, name := range names {
+		re, err := regexp.Compile(config.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("compiling pattern %q: %w", name, err)
+		}
+		w.patterns = append(w.patterns, logPattern{name: name, re: re, value: re.SubexpIndex("value")})
+	}
+
+	// LoadConfig drops repeated files, which would count every match twice.
+	for _, path := range config.Files {
+		w.files = append(w.files, &tailedFile{path: path})
+
+		stats := make([]*models.LogPatternStats, len(w.patterns))
+		for i, p := range w.patterns {
+			stats[i] = &models.LogPatternStats{File: path, Pattern: p.name}
+			if p.value >= 0 {
+				stats[i].Values = &models.HistogramStats{
+					Buckets: buckets,
+					Counts:  make([]uint64, len(buckets)),
+				}
+			}
+		}
+		w.stats[path] = stats
+	}
+
+	return w, nil
+}
+
+// GetLogWatchStats polls the process-wide log watcher, creating it from
+// config on first use.
+func GetLogWatchStats(config models.LogWatchConfig, logger *slog.Logger) (*models.LogWatchStats, error) {
+	logWatcherOnce.Do(func() {
+		logWatcher, logWatcherErr = NewLogWatcher(config, logger)
+	})
+	if logWatcherErr != nil {
+		return nil, logWatcherErr
+	}
+	return logWatcher.Collect()
+}
+
+// Collect reads new lines from every file and returns a snapshot of the
+// counters. A file that cannot be read is logged and skipped.
+func (w *LogWatcher) Collect() (*models.LogWatchStats, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, f := range w.files {
+		lines, err := f.poll()
+		if err != nil {
+			w.logger.Warn("Failed to read log file", "file", f.path, "error", err)
+		}
+		for _, line := range lines {
+			w.match(f.path, line)
+		}
+	}
+
+	result := &models.LogWatchStats{Timestamp: time.Now()}
+	for _, f := range w.files {
+		for _, s := range w.stats[f.path] {
+			snapshot := *s
+			if s.Values != nil {
+				values := *s.Values
+				values.Counts = append([]uint64(nil), s.Values.Counts...)
+				snapshot.Values = &values
+			}
+			result.Patterns = append(result.Patterns, snapshot)
+		}
+	}
+	return result, nil
+}
+
+func (w *LogWatcher) match(path, line string) {
+	stats := w.stats[path]
+	for i, p := range w.patterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		stats[i].Matches++
+
+		if p.value < 0 || m[p.value] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[p.value], 64)
+		if err != nil {
+			continue
+		}
+		h := stats[i].Values
+		h.Sum += v
+		h.Count++
+		for j, bound := range h.Buckets {
+			if v <= bound {
+				h.Counts[j]++
+			}
+		}
+	}
+}
+
+// poll returns the complete lines appended since the previous call. The first
+// poll starts at the end of an existing file so history is not counted; files
+// that appear later, or replace a rotated file, are read from the beginning.
+func (t *tailedFile) poll() ([]string, error) {
+	firstPoll := !t.polled
+	t.polled = true
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) && t.file != nil {
+			// Rotated away and not recreated yet: finish the old file.
+			return t.drain()
+		}
+		return nil, err
+	}
+
+	var lines []string
+	if t.file != nil && !os.SameFile(t.info, info) {
+		lines, err = t.drain()
+		if len(t.partial) > 0 {
+			// The rotated file will not grow again, so its last line is complete.
+			lines = append(lines, string(t.partial))
+		}
+		t.close()
+		if err != nil {
+			return lines, err
+		}
+	}
+
+	if t.file == nil {
+		if err := t.open(firstPoll); err != nil {
+			return lines, err
+		}
+	}
+
+	if t.truncated(info) {
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return lines, err
+		}
+		t.offset = 0
+		t.partial = nil
+		t.tail = nil
+	}
+
+	more, err := t.drain()
+	return append(lines, more...), err
+}
+
+// truncated reports whether the file was truncated in place since the last
+// read, as copytruncate does. A file that has been written up to or past the
+// old offset again is recognised by the bytes before the offset changing.
+func (t *tailedFile) truncated(info os.FileInfo) bool {
+	if info.Size() < t.offset {
+		return true
+	}
+	if len(t.tail) == 0 {
+		return false
+	}
+	buf := make([]byte, len(t.tail))
+	if _, err := t.file.ReadAt(buf, t.offset-int64(len(buf))); err != nil {
+		return true
+	}
+	return !bytes.Equal(buf, t.tail)
+}
+
+func (t *tailedFile) open(atEnd bool) error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	t.file = f
+	t.info = info
+	t.offset = 0
+	t.partial = nil
+
+	if atEnd {
+		offset, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			t.close()
+			return err
+		}
+		t.offset = offset
+
+		t.tail = make([]byte, min(offset, logFingerprintSize))
+		if _, err := f.ReadAt(t.tail, offset-int64(len(t.tail))); err != nil {
+			t.close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *tailedFile) close() {
+	if t.file != nil {
+		t.file.Close()
+	}
+	t.file = nil
+	t.info = nil
+	t.offset = 0
+	t.partial = nil
+	t.tail = nil
+}
+
+func (t *tailedFile) drain() ([]string, error) {
+	var lines []string
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := t.file.Read(buf)
+		if n > 0 {
+			t.offset += int64(n)
+			t.tail = append(t.tail, buf[:n]...)
+			t.tail = t.tail[max(0, len(t.tail)-logFingerprintSize):]
+			data := append(t.partial, buf[:n]...)
+			for {
+				i := bytes.IndexByte(data, '\n')
+				if i < 0 {
+					break
+				}
+				lines = append(lines, strings.TrimSuffix(string(data[:i]), "\r"))
+				data = data[i+1:]
+			}
+			t.partial = append([]byte(nil), data...)
+			if len(t.partial) > maxLogLineLength {
+				lines = append(lines, string(t.partial))
+				t.partial = nil
+			}
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"system-monitoring/models"
+)
+
+func newTestLogWatcher(t *testing.T, path string) *LogWatcher {
+	t.Helper()
+	w, err := NewLogWatcher(models.LogWatchConfig{
+		Files: []string{path},
+		Patterns: map[string]string{
+			"error": `error`,
+			"slow":  `took (?P<value>[0-9.]+)s`,
+		},
+		Buckets: []float64{1, 0.1},
+	}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// logMatches returns the match counts of the error and slow patterns.
+func logMatches(t *testing.T, w *LogWatcher) (errors, slow int64) {
+	t.Helper()
+	stats, err := w.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range stats.Patterns {
+		switch p.Pattern {
+		case "error":
+			errors = p.Matches
+		case "slow":
+			slow = p.Matches
+		}
+	}
+	return errors, slow
+}
+
+func appendLog(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogWatcherSkipsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLog(t, path, "error a\nerror b\n")
+	w := newTestLogWatcher(t, path)
+
+	if errs, _ := logMatches(t, w); errs != 0 {
+		t.Errorf("existing lines counted: %d", errs)
+	}
+	appendLog(t, path, "error c\n")
+	if errs, _ := logMatches(t, w); errs != 1 {
+		t.Errorf("matches = %d, want 1", errs)
+	}
+}
+
+func TestLogWatcherPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLog(t, path, "")
+	w := newTestLogWatcher(t, path)
+	logMatches(t, w)
+
+	appendLog(t, path, "err")
+	if errs, _ := logMatches(t, w); errs != 0 {
+		t.Errorf("unterminated line matched: %d", errs)
+	}
+	appendLog(t, path, "or a\r\n")
+	if errs, _ := logMatches(t, w); errs != 1 {
+		t.Errorf("matches after completing the line = %d, want 1", errs)
+	}
+}
+
+func TestLogWatcherRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLog(t, path, "")
+	w := newTestLogWatcher(t, path)
+	logMatches(t, w)
+
+	// Lines written just before the rename, including an unterminated last
+	// one, are still read from the rotated file.
+	appendLog(t, path, "error a\nerror b")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if errs, _ := logMatches(t, w); errs != 1 {
+		t.Errorf("matches while rotated away = %d, want 1", errs)
+	}
+
+	appendLog(t, path, "error c\n")
+	if errs, _ := logMatches(t, w); errs != 3 {
+		t.Errorf("matches after the new file appeared = %d, want 3", errs)
+	}
+}
+
+func TestLogWatcherTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLog(t, path, "")
+	w := newTestLogWatcher(t, path)
+	logMatches(t, w)
+
+	appendLog(t, path, "error a\nerror b\n")
+	if errs, _ := logMatches(t, w); errs != 2 {
+		t.Fatalf("matches = %d, want 2", errs)
+	}
+
+	// copytruncate, then the file grows back to a shorter size.
+	if err := os.WriteFile(path, []byte("error c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if errs, _ := logMatches(t, w); errs != 3 {
+		t.Errorf("matches after truncation = %d, want 3", errs)
+	}
+
+	// copytruncate, then the file grows back to exactly its old size.
+	if err := os.WriteFile(path, []byte("error d\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if errs, _ := logMatches(t, w); errs != 4 {
+		t.Errorf("matches after a same-size rewrite = %d, want 4", errs)
+	}
+
+	// and past it.
+	if err := os.WriteFile(path, []byte("error e\nerror f\nerror g\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if errs, _ := logMatches(t, w); errs != 7 {
+		t.Errorf("matches after a longer rewrite = %d, want 7", errs)
+	}
+}
+
+func TestLogWatcherValueHistogram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLog(t, path, "")
+	w := newTestLogWatcher(t, path)
+	logMatches(t, w)
+
+	appendLog(t, path, "took 0.05s\ntook 0.5s\ntook 3s\ntook .s\n")
+	stats, err := w.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h *models.HistogramStats
+	for _, p := range stats.Patterns {
+		if p.Pattern == "error" && p.Values != nil {
+			t.Error("pattern without a value group has a histogram")
+		}
+		if p.Pattern == "slow" {
+			if p.Matches != 4 {
+				t.Errorf("slow matches = %d, want 4", p.Matches)
+			}
+			h = p.Values
+		}
+	}
+	if h == nil {
+		t.Fatal("no histogram for the slow pattern")
+	}
+	// The buckets are sorted; the line without a number matches but is not
+	// observed.
+	if !slices.Equal(h.Buckets, []float64{0.1, 1}) || !slices.Equal(h.Counts, []uint64{1, 2}) || h.Count != 3 || h.Sum != 3.55 {
+		t.Errorf("histogram = %+v", *h)
+	}
+}
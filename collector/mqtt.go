@@ -0,0 +1,492 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttPubrec     = 5
+	mqttPubrel     = 6
+	mqttPubcomp    = 7
+	mqttPingreq    = 12
+	mqttPingresp   = 13
+	mqttDisconnect = 14
+)
+
+const (
+	mqttKeepAlive = 60
+	// mqttInflightWindow bounds the QoS 1 and 2 messages awaiting
+	// acknowledgement before more are sent.
+	mqttInflightWindow = 100
+)
+
+var mqttConnackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+var mqttTopicEscaper = strings.NewReplacer("/", "_", "+", "_", "#", "_", "\x00", "_")
+
+// MQTTClient publishes metrics to an MQTT broker using MQTT 3.1.1. The
+// connection is kept between cycles and reopened after any error; sessions are
+// clean, so nothing is queued by the broker while the agent is away.
+type MQTTClient struct {
+	config    models.MQTTConfig
+	address   string
+	tlsConfig *tls.Config
+	clientID  string
+	host      string
+	logger    *slog.Logger
+
+	mu   sync.Mutex
+	conn *mqttConn
+}
+
+func NewMQTTClient(config models.MQTTConfig, logger *slog.Logger) (*MQTTClient, error) {
+	if config.Mode != "batch" && config.Mode != "sample" {
+		return nil, fmt.Errorf("unsupported MQTT mode %q", config.Mode)
+	}
+	if config.QoS < 0 || config.QoS > 2 {
+		return nil, fmt.Errorf("unsupported MQTT QoS %d", config.QoS)
+	}
+
+	broker, err := url.Parse(config.Broker)
+	if err != nil {
+		return nil, fmt.Errorf("parsing MQTT broker URL: %w", err)
+	}
+
+	m := &MQTTClient{config: config, logger: logger}
+	port := "1883"
+	switch broker.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		port = "8883"
+		if m.tlsConfig, err = mqttTLSConfig(config); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported MQTT broker scheme %q", broker.Scheme)
+	}
+	if broker.Port() != "" {
+		port = broker.Port()
+	}
+	m.address = net.JoinHostPort(broker.Hostname(), port)
+
+	m.host, _ = os.Hostname()
+	m.clientID = config.ClientID
+	if m.clientID == "" {
+		m.clientID = "system-monitoring-" + m.host
+	}
+	return m, nil
+}
+
+func mqttTLSConfig(config models.MQTTConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.TLSInsecureSkipVerify}
+
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading MQTT CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.TLSCAFile)
+		}
+	}
+	if config.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading MQTT client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (m *MQTTClient) Name() string {
+	return "mqtt"
+}
+
+// Ping checks an open connection with a PINGREQ, or connects to the broker,
+// which also checks the credentials.
+func (m *MQTTClient) Ping(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conn != nil {
+		m.conn.setDeadline(ctx)
+		if err := m.conn.ping(); err == nil {
+			return nil
+		}
+		// The broker may have dropped an idle connection; try a new one.
+		m.closeConn()
+	}
+	_, err := m.session(ctx)
+	return err
+}
+
+func (m *MQTTClient) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	messages, err := m.messages(metrics, timestamp)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		m.logger.DebugContext(ctx, "No metrics to send")
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conn, err := m.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.logger.DebugContext(ctx, "Publishing metrics to MQTT",
+		"messages", len(messages),
+		"broker", m.address)
+
+	if err := conn.publish(messages, byte(m.config.QoS), m.config.Retained); err != nil {
+		m.closeConn()
+		return fmt.Errorf("publishing to MQTT broker: %w", err)
+	}
+	return nil
+}
+
+func (m *MQTTClient) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		return nil
+	}
+	err := m.conn.disconnect()
+	m.closeConn()
+	return err
+}
+
+// session returns the open connection, connecting first if there is none.
+// The caller holds m.mu.
+func (m *MQTTClient) session(ctx context.Context) (*mqttConn, error) {
+	if m.conn != nil {
+		m.conn.setDeadline(ctx)
+		return m.conn, nil
+	}
+	conn, err := m.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.conn = conn
+	return conn, nil
+}
+
+func (m *MQTTClient) closeConn() {
+	if m.conn != nil {
+		m.conn.Close()
+		m.conn = nil
+	}
+}
+
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+type mqttBatch struct {
+	Host      string       `json:"host"`
+	Timestamp int64        `json:"timestamp"`
+	Metrics   []jsonSample `json:"metrics"`
+}
+
+// messages renders the topic of every series and builds one message per
+// series in sample mode, or one per topic in batch mode. JSON has no NaN or
+// infinities, so such values are skipped.
+func (m *MQTTClient) messages(metrics map[string]float64, timestamp time.Time) ([]mqttMessage, error) {
+	batches := make(map[string]*mqttBatch)
+	var topics []string
+	var messages []mqttMessage
+
+	for _, s := range parseBatch(metrics) {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+
+		topic := strings.NewReplacer(
+			"{host}", mqttTopicEscaper.Replace(m.host),
+			"{collector}", mqttTopicEscaper.Replace(collectorOf(s.Name)),
+			"{name}", mqttTopicEscaper.Replace(s.Name),
+		).Replace(m.config.Topic)
+
+		sample := newJSONSample(s)
+
+		if m.config.Mode == "sample" {
+			sample.Timestamp = timestamp.UnixMilli()
+			payload, err := json.Marshal(sample)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, mqttMessage{topic: topic, payload: payload})
+			continue
+		}
+
+		batch, ok := batches[topic]
+		if !ok {
+			batch = &mqttBatch{Host: m.host, Timestamp: timestamp.UnixMilli()}
+			batches[topic] = batch
+			topics = append(topics, topic)
+		}
+		batch.Metrics = append(batch.Metrics, sample)
+	}
+
+	for _, topic := range topics {
+		payload, err := json.Marshal(batches[topic])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, mqttMessage{topic: topic, payload: payload})
+	}
+	return messages, nil
+}
+
+type mqttConn struct {
+	net.Conn
+	r      *bufio.Reader
+	w      *bufio.Writer
+	nextID uint16
+}
+
+// connect opens a clean session. The context deadline applies until the next
+// setDeadline.
+func (m *MQTTClient) connect(ctx context.Context) (*mqttConn, error) {
+	var conn net.Conn
+	var err error
+	if m.tlsConfig != nil {
+		dialer := tls.Dialer{Config: m.tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", m.address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", m.address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MQTT broker: %w", err)
+	}
+	c := &mqttConn{Conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	c.setDeadline(ctx)
+
+	flags := byte(0x02) // clean session
+	var payload []byte
+	payload = appendMQTTString(payload, m.clientID)
+	if m.config.Username != "" {
+		flags |= 0x80
+		payload = appendMQTTString(payload, m.config.Username)
+		if m.config.Password != "" {
+			flags |= 0x40
+			payload = appendMQTTString(payload, m.config.Password)
+		}
+	}
+
+	var body []byte
+	body = appendMQTTString(body, "MQTT")
+	body = append(body, 4, flags) // protocol level 3.1.1
+	body = binary.BigEndian.AppendUint16(body, mqttKeepAlive)
+	body = append(body, payload...)
+
+	err = c.writePacket(mqttConnect<<4, body)
+	if err == nil {
+		err = c.w.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("sending MQTT CONNECT: %w", err)
+	}
+
+	packetType, ack, err := c.readPacket()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading MQTT CONNACK: %w", err)
+	}
+	if packetType>>4 != mqttConnack || len(ack) != 2 {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected MQTT packet type %d instead of CONNACK", packetType>>4)
+	}
+	if ack[1] != 0 {
+		conn.Close()
+		if reason, ok := mqttConnackErrors[ack[1]]; ok {
+			return nil, fmt.Errorf("MQTT broker refused connection: %s", reason)
+		}
+		return nil, fmt.Errorf("MQTT broker refused connection: code %d", ack[1])
+	}
+	return c, nil
+}
+
+// publish sends the messages and, for QoS 1 and 2, completes their
+// acknowledgement flows in windows of mqttInflightWindow messages.
+func (c *mqttConn) publish(messages []mqttMessage, qos byte, retained bool) error {
+	header := byte(mqttPublish<<4) | qos<<1
+	if retained {
+		header |= 0x01
+	}
+
+	for len(messages) > 0 {
+		window := messages
+		if qos > 0 {
+			window = messages[:min(len(messages), mqttInflightWindow)]
+		}
+		messages = messages[len(window):]
+
+		pending := make(map[uint16]bool)
+		for _, msg := range window {
+			body := appendMQTTString(nil, msg.topic)
+			if qos > 0 {
+				c.nextID++
+				if c.nextID == 0 {
+					c.nextID = 1
+				}
+				body = binary.BigEndian.AppendUint16(body, c.nextID)
+				pending[c.nextID] = true
+			}
+			body = append(body, msg.payload...)
+			if err := c.writePacket(header, body); err != nil {
+				return err
+			}
+		}
+		if err := c.w.Flush(); err != nil {
+			return err
+		}
+
+		for len(pending) > 0 {
+			packetType, body, err := c.readPacket()
+			if err != nil {
+				return err
+			}
+			if len(body) < 2 {
+				return fmt.Errorf("malformed MQTT packet type %d", packetType>>4)
+			}
+			id := binary.BigEndian.Uint16(body)
+
+			switch packetType >> 4 {
+			case mqttPuback, mqttPubcomp:
+				delete(pending, id)
+			case mqttPubrec:
+				if err := c.writePacket(mqttPubrel<<4|0x02, body[:2]); err != nil {
+					return err
+				}
+				if err := c.w.Flush(); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unexpected MQTT packet type %d", packetType>>4)
+			}
+		}
+	}
+	return nil
+}
+
+// setDeadline bounds the following reads and writes by the context deadline,
+// or lifts the bound when there is none.
+func (c *mqttConn) setDeadline(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	c.SetDeadline(deadline)
+}
+
+func (c *mqttConn) ping() error {
+	if err := c.writePacket(mqttPingreq<<4, nil); err != nil {
+		return err
+	}
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	packetType, _, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	if packetType>>4 != mqttPingresp {
+		return fmt.Errorf("unexpected MQTT packet type %d instead of PINGRESP", packetType>>4)
+	}
+	return nil
+}
+
+func (c *mqttConn) disconnect() error {
+	if err := c.writePacket(mqttDisconnect<<4, nil); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func (c *mqttConn) writePacket(header byte, body []byte) error {
+	c.w.WriteByte(header)
+	// Remaining length: 7 bits per byte, least significant group first.
+	n := len(body)
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n > 0 {
+			b |= 0x80
+		}
+		c.w.WriteByte(b)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := c.w.Write(body)
+	return err
+}
+
+func (c *mqttConn) readPacket() (byte, []byte, error) {
+	header, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length := 0
+	for shift := 0; ; shift += 7 {
+		if shift > 21 {
+			return 0, nil, errors.New("malformed MQTT remaining length")
+		}
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func appendMQTTString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
@@ -0,0 +1,326 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+type brokerMessage struct {
+	topic    string
+	payload  string
+	qos      byte
+	retained bool
+}
+
+// fakeBroker is an in-process MQTT 3.1.1 broker that accepts one client at a
+// time, records what is published and completes the QoS 1 and 2 flows.
+type fakeBroker struct {
+	t        *testing.T
+	listener net.Listener
+	connack  byte // CONNACK return code
+
+	mu          sync.Mutex
+	connections int
+	connects    []string // client IDs
+	pings       int
+	messages    []brokerMessage
+	conns       []net.Conn
+}
+
+func newFakeBroker(t *testing.T, connack byte) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{t: t, listener: listener, connack: connack}
+	go b.serve()
+	t.Cleanup(func() { listener.Close() })
+	return b
+}
+
+func (b *fakeBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+// dropConnections closes the server side of every open connection.
+func (b *fakeBroker) dropConnections() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, c := range b.conns {
+		c.Close()
+	}
+}
+
+func (b *fakeBroker) received() []brokerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]brokerMessage(nil), b.messages...)
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.mu.Lock()
+		b.connections++
+		b.conns = append(b.conns, conn)
+		b.mu.Unlock()
+		go b.handle(conn)
+	}
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	write := func(packet ...byte) { conn.Write(packet) }
+
+	for {
+		header, body, err := readTestPacket(r)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case mqttConnect:
+			// protocol name, level, flags, keep alive, then the client ID
+			if string(body[2:6]) != "MQTT" || body[6] != 4 {
+				b.t.Errorf("unexpected CONNECT % x", body)
+			}
+			idLen := int(binary.BigEndian.Uint16(body[10:]))
+			b.mu.Lock()
+			b.connects = append(b.connects, string(body[12:12+idLen]))
+			b.mu.Unlock()
+			write(mqttConnack<<4, 2, 0, b.connack)
+			if b.connack != 0 {
+				return
+			}
+		case mqttPublish:
+			qos := header >> 1 & 3
+			topicLen := int(binary.BigEndian.Uint16(body))
+			msg := brokerMessage{topic: string(body[2 : 2+topicLen]), qos: qos, retained: header&1 == 1}
+			rest := body[2+topicLen:]
+			var id []byte
+			if qos > 0 {
+				id, rest = rest[:2], rest[2:]
+			}
+			msg.payload = string(rest)
+			b.mu.Lock()
+			b.messages = append(b.messages, msg)
+			b.mu.Unlock()
+			switch qos {
+			case 1:
+				write(mqttPuback<<4, 2, id[0], id[1])
+			case 2:
+				write(mqttPubrec<<4, 2, id[0], id[1])
+			}
+		case mqttPubrel:
+			if header&0x0f != 0x02 {
+				b.t.Errorf("PUBREL with flags %x", header&0x0f)
+			}
+			write(mqttPubcomp<<4, 2, body[0], body[1])
+		case mqttPingreq:
+			b.mu.Lock()
+			b.pings++
+			b.mu.Unlock()
+			write(mqttPingresp<<4, 0)
+		case mqttDisconnect:
+			return
+		default:
+			b.t.Errorf("unexpected packet type %d", header>>4)
+			return
+		}
+	}
+}
+
+func readTestPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+// settledMessages returns what the broker received once it has answered a
+// PINGREQ sent after the publishes; QoS 0 messages are not acknowledged.
+func settledMessages(t *testing.T, client *MQTTClient, broker *fakeBroker) []brokerMessage {
+	t.Helper()
+	if err := client.Ping(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+	return broker.received()
+}
+
+func newTestMQTTClient(t *testing.T, broker string, modify func(*models.MQTTConfig)) *MQTTClient {
+	t.Helper()
+	config := models.MQTTConfig{
+		Broker:   broker,
+		ClientID: "test-client",
+		Topic:    "sysmon/test/{collector}",
+		Mode:     "batch",
+	}
+	if modify != nil {
+		modify(&config)
+	}
+	client, err := NewMQTTClient(config, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestMQTTClientPublishQoS(t *testing.T) {
+	metrics := map[string]float64{
+		"cpu_usage_percent":                     12.5,
+		`memory_usage_percent`:                  40,
+		`kernel_messages_total{severity="err"}`: 2,
+	}
+
+	for _, qos := range []int{0, 1, 2} {
+		for _, retained := range []bool{false, true} {
+			t.Run(fmt.Sprintf("qos%d retained=%v", qos, retained), func(t *testing.T) {
+				broker := newFakeBroker(t, 0)
+				client := newTestMQTTClient(t, broker.url(), func(c *models.MQTTConfig) {
+					c.QoS = qos
+					c.Retained = retained
+				})
+
+				if err := client.SendMetrics(testContext(t), metrics, time.UnixMilli(1000)); err != nil {
+					t.Fatal(err)
+				}
+				got := settledMessages(t, client, broker)
+				if len(got) != 3 {
+					t.Fatalf("broker received %d messages, want one per collector", len(got))
+				}
+
+				topics := map[string]bool{}
+				for _, msg := range got {
+					topics[msg.topic] = true
+					if msg.qos != byte(qos) || msg.retained != retained {
+						t.Errorf("%s: qos %d retained %v", msg.topic, msg.qos, msg.retained)
+					}
+					var batch mqttBatch
+					if err := json.Unmarshal([]byte(msg.payload), &batch); err != nil || batch.Timestamp != 1000 || len(batch.Metrics) != 1 {
+						t.Errorf("%s: payload %s", msg.topic, msg.payload)
+					}
+				}
+				for _, want := range []string{"sysmon/test/cpu", "sysmon/test/memory", "sysmon/test/kmsg"} {
+					if !topics[want] {
+						t.Errorf("nothing published to %s", want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMQTTClientInflightWindow(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	client := newTestMQTTClient(t, broker.url(), func(c *models.MQTTConfig) {
+		c.QoS = 2
+		c.Mode = "sample"
+		c.Topic = "sysmon/{name}"
+	})
+
+	metrics := make(map[string]float64)
+	for i := 0; i < 2*mqttInflightWindow+5; i++ {
+		metrics[fmt.Sprintf(`interrupts_total{irq="%d"}`, i)] = float64(i)
+	}
+	if err := client.SendMetrics(testContext(t), metrics, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(broker.received()); got != len(metrics) {
+		t.Errorf("broker received %d messages, want %d", got, len(metrics))
+	}
+}
+
+func TestMQTTClientRejectedConnack(t *testing.T) {
+	broker := newFakeBroker(t, 5)
+	client := newTestMQTTClient(t, broker.url(), nil)
+
+	err := client.Ping(testContext(t))
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Fatalf("Ping error = %v, want not authorized", err)
+	}
+	if err := client.SendMetrics(testContext(t), map[string]float64{"cpu_usage_percent": 1}, time.Now()); err == nil {
+		t.Fatal("SendMetrics succeeded on a refused connection")
+	}
+	if got := broker.received(); len(got) != 0 {
+		t.Errorf("broker received %d messages", len(got))
+	}
+}
+
+func TestMQTTClientReusesConnection(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	client := newTestMQTTClient(t, broker.url(), nil)
+	metrics := map[string]float64{"cpu_usage_percent": 1}
+
+	for i := 0; i < 3; i++ {
+		if err := client.Ping(testContext(t)); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.SendMetrics(testContext(t), metrics, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	broker.mu.Lock()
+	connections, pings := broker.connections, broker.pings
+	broker.mu.Unlock()
+	if connections != 1 || pings != 2 {
+		t.Errorf("%d connections and %d pings for three cycles, want 1 and 2", connections, pings)
+	}
+
+	if got := len(settledMessages(t, client, broker)); got != 3 {
+		t.Errorf("broker received %d messages, want 3", got)
+	}
+
+	// After the broker drops the connection, Ping connects again.
+	broker.dropConnections()
+	if err := client.Ping(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendMetrics(testContext(t), metrics, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	broker.mu.Lock()
+	connections = broker.connections
+	broker.mu.Unlock()
+	if connections != 2 {
+		t.Errorf("%d connections after a drop, want 2", connections)
+	}
+	if got := len(settledMessages(t, client, broker)); got != 4 {
+		t.Errorf("broker received %d messages, want 4", got)
+	}
+}
@@ -0,0 +1,194 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+var bridgePortStates = []string{"disabled", "listening", "learning", "forwarding", "blocking"}
+
+// GetNetworkLinkStats reports wireless link quality, bonding slave status and
+// bridge port state. Each source is optional: hosts without wireless, bonding
+// or bridges simply produce no entries for it.
+func GetNetworkLinkStats(procPath, sysPath string) (*models.NetworkLinkStats, error) {
+	wireless, err := readWireless(filepath.Join(procPath, "net", "wireless"))
+	if err != nil {
+		return nil, err
+	}
+
+	bonds, err := readBonds(filepath.Join(procPath, "net", "bonding"))
+	if err != nil {
+		return nil, err
+	}
+
+	ports, err := readBridgePorts(filepath.Join(sysPath, "class", "net"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NetworkLinkStats{
+		Wireless:    wireless,
+		Bonds:       bonds,
+		BridgePorts: ports,
+		Timestamp:   time.Now(),
+	}, nil
+}
+
+// readWireless parses /proc/net/wireless, which after two header lines has
+// one line per interface:
+//
+//	wlan0: 0000   70.  -40.  -256        0      0      0      0      0        0
+func readWireless(path string) ([]models.WirelessStats, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []models.WirelessStats
+	for _, line := range strings.Split(string(data), "\n") {
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok || strings.Contains(name, "|") {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 4 {
+			continue
+		}
+
+		quality, err := parseWirelessValue(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		signal, err := parseWirelessValue(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		noise, err := parseWirelessValue(fields[3])
+		if err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, models.WirelessStats{
+			Interface: strings.TrimSpace(name),
+			Quality:   quality,
+			SignalDBm: signal,
+			NoiseDBm:  noise,
+			HasNoise:  noise > -256,
+		})
+	}
+	return stats, nil
+}
+
+// parseWirelessValue strips the trailing '.' the kernel appends to values that
+// were updated since the last read.
+func parseWirelessValue(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSuffix(s, "."), 64)
+}
+
+func readBonds(dir string) ([]models.BondStats, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var bonds []models.BondStats
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		bonds = append(bonds, parseBond(entry.Name(), string(data)))
+	}
+	return bonds, nil
+}
+
+// parseBond parses a /proc/net/bonding file. Settings before the first
+// "Slave Interface" line describe the bond itself, later ones the slave
+// named by the preceding "Slave Interface" line.
+func parseBond(name, data string) models.BondStats {
+	bond := models.BondStats{Name: name}
+	var activeSlave string
+	var slave *models.BondSlaveStats
+
+	for _, line := range strings.Split(data, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Bonding Mode":
+			bond.Mode = value
+		case "Currently Active Slave":
+			activeSlave = value
+		case "Slave Interface":
+			bond.Slaves = append(bond.Slaves, models.BondSlaveStats{Name: value})
+			slave = &bond.Slaves[len(bond.Slaves)-1]
+		case "MII Status":
+			if slave == nil {
+				bond.Up = value == "up"
+			} else {
+				slave.Up = value == "up"
+			}
+		case "Link Failure Count":
+			if slave != nil {
+				slave.LinkFailures, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+	}
+
+	for i := range bond.Slaves {
+		bond.Slaves[i].Active = bond.Slaves[i].Name == activeSlave
+	}
+	return bond
+}
+
+// readBridgePorts finds interfaces enslaved to a bridge through their brport
+// directory in /sys/class/net.
+func readBridgePorts(dir string) ([]models.BridgePortStats, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []models.BridgePortStats
+	for _, entry := range entries {
+		brport := filepath.Join(dir, entry.Name(), "brport")
+		state, err := readSysfsInt(filepath.Join(brport, "state"))
+		if err != nil {
+			continue
+		}
+
+		bridge, err := os.Readlink(filepath.Join(brport, "bridge"))
+		if err != nil {
+			continue
+		}
+
+		port := models.BridgePortStats{
+			Bridge: filepath.Base(bridge),
+			Port:   entry.Name(),
+			State:  int(state),
+		}
+		if state >= 0 && int(state) < len(bridgePortStates) {
+			port.StateName = bridgePortStates[state]
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"system-monitoring/models"
+)
+
+const bondFixture = `Ethernet Channel Bonding Driver: v5.15.0
+
+Bonding Mode: fault-tolerance (active-backup)
+Primary Slave: None
+Currently Active Slave: eth1
+MII Status: up
+MII Polling Interval (ms): 100
+
+Slave Interface: eth0
+MII Status: down
+Speed: Unknown
+Link Failure Count: 3
+Permanent HW addr: 52:54:00:12:34:56
+
+Slave Interface: eth1
+MII Status: up
+Speed: 1000 Mbps
+Link Failure Count: 0
+Permanent HW addr: 52:54:00:12:34:57
+`
+
+func TestGetNetworkLinkStatsFixture(t *testing.T) {
+	proc, sys := t.TempDir(), t.TempDir()
+	writeTree(t, proc, map[string]string{
+		"net/wireless": "Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE\n" +
+			" face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22\n" +
+			" wlan0: 0000   70.  -40.  -256        0      0      0      0      0        0\n" +
+			" wlan1: 0000   55   -61   -92         0      0      0      0      0        0\n",
+		"net/bonding/bond0": bondFixture,
+	})
+	writeTree(t, sys, map[string]string{
+		"class/net/eth2/brport/state": "3\n",
+		"class/net/eth3/brport/state": "4\n",
+		"class/net/eth4/mtu":          "1500\n", // not a bridge port
+		"devices/virtual/net/br0/mtu": "1500\n",
+	})
+	for _, port := range []string{"eth2", "eth3"} {
+		if err := os.Symlink("../../../../devices/virtual/net/br0", filepath.Join(sys, "class/net", port, "brport/bridge")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := GetNetworkLinkStats(proc, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wireless := []models.WirelessStats{
+		{Interface: "wlan0", Quality: 70, SignalDBm: -40, NoiseDBm: -256},
+		{Interface: "wlan1", Quality: 55, SignalDBm: -61, NoiseDBm: -92, HasNoise: true},
+	}
+	if !reflect.DeepEqual(stats.Wireless, wireless) {
+		t.Errorf("wireless = %+v, want %+v", stats.Wireless, wireless)
+	}
+
+	bonds := []models.BondStats{{
+		Name: "bond0",
+		Mode: "fault-tolerance (active-backup)",
+		Up:   true,
+		Slaves: []models.BondSlaveStats{
+			{Name: "eth0", LinkFailures: 3},
+			{Name: "eth1", Up: true, Active: true},
+		},
+	}}
+	if !reflect.DeepEqual(stats.Bonds, bonds) {
+		t.Errorf("bonds = %+v, want %+v", stats.Bonds, bonds)
+	}
+
+	ports := []models.BridgePortStats{
+		{Bridge: "br0", Port: "eth2", State: 3, StateName: "forwarding"},
+		{Bridge: "br0", Port: "eth3", State: 4, StateName: "blocking"},
+	}
+	if !reflect.DeepEqual(stats.BridgePorts, ports) {
+		t.Errorf("bridge ports = %+v, want %+v", stats.BridgePorts, ports)
+	}
+}
+
+func TestGetNetworkLinkStatsEmpty(t *testing.T) {
+	stats, err := GetNetworkLinkStats(t.TempDir(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Wireless) != 0 || len(stats.Bonds) != 0 || len(stats.BridgePorts) != 0 {
+		t.Errorf("stats = %+v, want no entries", stats)
+	}
+}
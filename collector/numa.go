@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// GetNUMAStats reads per-node memory and allocation counters. Kernels built
+// without NUMA support have no node directory and yield no nodes.
+func GetNUMAStats(sysPath string) (*models.NUMAStats, error) {
+	dirs, err := filepath.Glob(filepath.Join(sysPath, "devices", "system", "node", "node[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+
+	stats := &models.NUMAStats{Timestamp: time.Now()}
+	for _, dir := range dirs {
+		node := models.NUMANodeStats{Node: strings.TrimPrefix(filepath.Base(dir), "node")}
+
+		data, err := os.ReadFile(filepath.Join(dir, "meminfo"))
+		if err != nil {
+			return nil, err
+		}
+		// Lines look like "Node 0 MemTotal:       16333028 kB".
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				continue
+			}
+			value, err := strconv.ParseInt(fields[3], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			switch fields[2] {
+			case "MemTotal:":
+				node.Total = value * 1024
+			case "MemFree:":
+				node.Free = value * 1024
+			case "MemUsed:":
+				node.Used = value * 1024
+			}
+		}
+
+		data, err = os.ReadFile(filepath.Join(dir, "numastat"))
+		if err != nil {
+			return nil, err
+		}
+		node.Stats = make(map[string]uint64)
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			node.Stats[fields[0]] = value
+		}
+
+		stats.Nodes = append(stats.Nodes, node)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"system-monitoring/models"
+)
+
+const numastatFixture = `numa_hit 1000
+numa_miss 20
+numa_foreign 5
+interleave_hit 7
+local_node 990
+other_node 30
+`
+
+func TestGetNUMAStatsFixture(t *testing.T) {
+	sys := t.TempDir()
+	writeTree(t, sys, map[string]string{
+		"devices/system/node/online": "0-1\n",
+		"devices/system/node/node0/meminfo": "Node 0 MemTotal:       16333028 kB\n" +
+			"Node 0 MemFree:         1024000 kB\n" +
+			"Node 0 MemUsed:        15309028 kB\n" +
+			"Node 0 HugePages_Total:     0\n",
+		"devices/system/node/node0/numastat": numastatFixture,
+		"devices/system/node/node1/meminfo": "Node 1 MemTotal:        8000000 kB\n" +
+			"Node 1 MemFree:         2000000 kB\n" +
+			"Node 1 MemUsed:         6000000 kB\n",
+		"devices/system/node/node1/numastat": "numa_hit 1\nnuma_miss 0\n",
+	})
+
+	stats, err := GetNUMAStats(sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.NUMANodeStats{
+		{
+			Node:  "0",
+			Total: 16333028 * 1024,
+			Free:  1024000 * 1024,
+			Used:  15309028 * 1024,
+			Stats: map[string]uint64{
+				"numa_hit": 1000, "numa_miss": 20, "numa_foreign": 5,
+				"interleave_hit": 7, "local_node": 990, "other_node": 30,
+			},
+		},
+		{
+			Node:  "1",
+			Total: 8000000 * 1024,
+			Free:  2000000 * 1024,
+			Used:  6000000 * 1024,
+			Stats: map[string]uint64{"numa_hit": 1, "numa_miss": 0},
+		},
+	}
+	if !reflect.DeepEqual(stats.Nodes, want) {
+		t.Errorf("nodes = %+v, want %+v", stats.Nodes, want)
+	}
+}
+
+func TestGetNUMAStatsWithoutNUMA(t *testing.T) {
+	stats, err := GetNUMAStats(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Nodes) != 0 {
+		t.Errorf("nodes = %+v, want none", stats.Nodes)
+	}
+}
@@ -0,0 +1,503 @@
+package collector
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+const otlpTemporalityCumulative = 2
+
+// processCounterCollectors are the collectors whose counters start at zero
+// with this process. The other counters are kept by the kernel since boot.
+var processCounterCollectors = map[string]bool{
+	"logwatch":  true,
+	"kmsg":      true,
+	"sessions":  true,
+	"listeners": true,
+	"integrity": true,
+	"buffer":    true,
+}
+
+// OTLPExporter pushes metrics to an OpenTelemetry Collector or any other
+// OTLP/HTTP receiver. Gauges become OTLP gauges, _total counters cumulative
+// monotonic sums and histograms explicit bucket histograms, all under a
+// resource describing this host.
+type OTLPExporter struct {
+	config   models.OTLPConfig
+	resource []otlpKeyValue
+	start    otlpStartTimes
+	delivery *httpDelivery
+	logger   *slog.Logger
+}
+
+// otlpStartTimes are the start times of cumulative series: the process start
+// for counters kept by this process, and boot for those read from the
+// kernel. Container counters also use boot, which precedes their real start.
+type otlpStartTimes struct {
+	process time.Time
+	boot    time.Time
+}
+
+func (t otlpStartTimes) of(family string) uint64 {
+	if processCounterCollectors[collectorOf(family)] || t.boot.IsZero() {
+		return uint64(t.process.UnixNano())
+	}
+	return uint64(t.boot.UnixNano())
+}
+
+// NewOTLPExporter creates the exporter. inventory supplies the host and OS
+// resource attributes and the boot time, and may be nil; kernel counters then
+// start with the process.
+func NewOTLPExporter(config models.OTLPConfig, database models.DatabaseConfig, inventory *models.InventoryStats, logger *slog.Logger) (*OTLPExporter, error) {
+	if config.Protocol != "http/protobuf" && config.Protocol != "http/json" {
+		return nil, fmt.Errorf("unsupported OTLP protocol %q", config.Protocol)
+	}
+	start := otlpStartTimes{process: time.Now()}
+	if inventory != nil {
+		start.boot = inventory.BootTime
+	}
+	return &OTLPExporter{
+		config:   config,
+		resource: otlpResource(config.ServiceName, inventory),
+		start:    start,
+		delivery: newHTTPDelivery(database, logger),
+		logger:   logger,
+	}, nil
+}
+
+func (o *OTLPExporter) Name() string {
+	return "otlp"
+}
+
+// Ping always succeeds: OTLP defines no health endpoint, so an unreachable
+// receiver only shows up when sending.
+func (o *OTLPExporter) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (o *OTLPExporter) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	if len(metrics) == 0 {
+		o.logger.DebugContext(ctx, "No metrics to send")
+		return nil
+	}
+
+	header := http.Header{}
+	for k, v := range o.config.Headers {
+		header.Set(k, v)
+	}
+
+	all := buildOTLPMetrics(parseBatch(metrics), o.start, timestamp)
+	for _, batch := range chunkOTLPMetrics(all, o.delivery.batchSize) {
+		request := otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+			Resource: otlpResourceAttributes{Attributes: o.resource},
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope:   otlpScope{Name: "system-monitoring"},
+				Metrics: batch,
+			}},
+		}}}
+
+		var body []byte
+		if o.config.Protocol == "http/json" {
+			var err error
+			if body, err = json.Marshal(request); err != nil {
+				return fmt.Errorf("encoding OTLP request: %w", err)
+			}
+			header.Set("Content-Type", "application/json")
+		} else {
+			body = request.marshalProto()
+			header.Set("Content-Type", "application/x-protobuf")
+		}
+
+		o.logger.DebugContext(ctx, "Sending metrics via OTLP",
+			"metrics", len(batch),
+			"bytes", len(body),
+			"url", o.config.Endpoint)
+
+		if err := o.delivery.post(ctx, o.config.Endpoint, header, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OTLPExporter) Close() error {
+	o.delivery.client.CloseIdleConnections()
+	return nil
+}
+
+// otlpResource builds the resource attributes following the OpenTelemetry
+// semantic conventions for hosts and operating systems.
+func otlpResource(serviceName string, inventory *models.InventoryStats) []otlpKeyValue {
+	attrs := []otlpKeyValue{
+		otlpAttribute("service.name", serviceName),
+		otlpAttribute("host.arch", otlpHostArch()),
+		otlpAttribute("os.type", runtime.GOOS),
+	}
+	if inventory == nil {
+		return attrs
+	}
+	for _, a := range []otlpKeyValue{
+		otlpAttribute("host.name", inventory.Hostname),
+		otlpAttribute("os.name", inventory.OSName),
+		otlpAttribute("os.version", inventory.OSVersion),
+		otlpAttribute("os.description", inventory.KernelRelease),
+	} {
+		if a.Value.StringValue != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	return attrs
+}
+
+func otlpHostArch() string {
+	switch runtime.GOARCH {
+	case "386":
+		return "x86"
+	case "arm":
+		return "arm32"
+	default:
+		return runtime.GOARCH
+	}
+}
+
+// buildOTLPMetrics converts the sorted series into one OTLP metric per
+// family. Counters drop their _total suffix, as the OpenTelemetry Collector
+// adds it back when exporting to Prometheus.
+func buildOTLPMetrics(series []parsedSeries, start otlpStartTimes, now time.Time) []otlpMetric {
+	nowNano := uint64(now.UnixNano())
+	var out []otlpMetric
+
+	for i := 0; i < len(series); {
+		j := i
+		for j < len(series) && series[j].Family == series[i].Family {
+			j++
+		}
+		family, group := series[i].Family, series[i:j]
+		i = j
+
+		metric := otlpMetric{Name: family.Name, Description: metricHelp(family.Name)}
+		switch family.Type {
+		case "counter":
+			metric.Name = strings.TrimSuffix(family.Name, "_total")
+			metric.Sum = &otlpSum{
+				DataPoints:             otlpNumberPoints(group, start.of(family.Name), nowNano),
+				AggregationTemporality: otlpTemporalityCumulative,
+				IsMonotonic:            true,
+			}
+		case "histogram":
+			metric.Histogram = &otlpHistogram{
+				DataPoints:             otlpHistogramPoints(group, start.of(family.Name), nowNano),
+				AggregationTemporality: otlpTemporalityCumulative,
+			}
+		default:
+			metric.Gauge = &otlpGauge{DataPoints: otlpNumberPoints(group, 0, nowNano)}
+		}
+		out = append(out, metric)
+	}
+	return out
+}
+
+func otlpNumberPoints(series []parsedSeries, startNano, nowNano uint64) []otlpNumberPoint {
+	points := make([]otlpNumberPoint, 0, len(series))
+	for _, s := range series {
+		points = append(points, otlpNumberPoint{
+			Attributes:        otlpAttributes(s.Labels),
+			StartTimeUnixNano: startNano,
+			TimeUnixNano:      nowNano,
+			AsDouble:          otlpDouble(s.Value),
+		})
+	}
+	return points
+}
+
+// otlpHistogramPoints reassembles the _bucket, _count and _sum series of each
+// label set, which parseBatch orders together with buckets first, and turns
+// the cumulative bucket counts into per bucket counts.
+func otlpHistogramPoints(series []parsedSeries, startNano, nowNano uint64) []otlpHistogramPoint {
+	var points []otlpHistogramPoint
+	var point *otlpHistogramPoint
+	var key string
+	var cumulative float64
+
+	for _, s := range series {
+		if k := labelsKey(s.Labels); point == nil || k != key {
+			points = append(points, otlpHistogramPoint{
+				Attributes:        otlpAttributes(s.Labels),
+				StartTimeUnixNano: startNano,
+				TimeUnixNano:      nowNano,
+			})
+			point, key, cumulative = &points[len(points)-1], k, 0
+		}
+
+		switch histogramPart(s) {
+		case 0:
+			bound := bucketBound(s.Labels)
+			if !math.IsInf(bound, 1) {
+				point.ExplicitBounds = append(point.ExplicitBounds, otlpDouble(bound))
+			}
+			point.BucketCounts = append(point.BucketCounts, otlpUint64(s.Value-cumulative))
+			cumulative = s.Value
+		case 1:
+			point.Count = uint64(s.Value)
+		default:
+			point.Sum = otlpDouble(s.Value)
+		}
+	}
+	return points
+}
+
+func otlpAttributes(labels []seriesLabel) []otlpKeyValue {
+	var attrs []otlpKeyValue
+	for _, l := range labels {
+		if l.Name != "le" {
+			attrs = append(attrs, otlpAttribute(l.Name, l.Value))
+		}
+	}
+	return attrs
+}
+
+// chunkOTLPMetrics groups metrics into requests of about batchSize data
+// points. A metric is never split, so its points arrive together.
+func chunkOTLPMetrics(metrics []otlpMetric, batchSize int) [][]otlpMetric {
+	var chunks [][]otlpMetric
+	var chunk []otlpMetric
+	points := 0
+	for _, m := range metrics {
+		if len(chunk) > 0 && batchSize > 0 && points+m.pointCount() > batchSize {
+			chunks = append(chunks, chunk)
+			chunk, points = nil, 0
+		}
+		chunk = append(chunk, m)
+		points += m.pointCount()
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// The types below mirror the messages of
+// opentelemetry.proto.collector.metrics.v1.ExportMetricsServiceRequest. Their
+// JSON tags follow the OTLP/JSON encoding, where 64 bit integers are strings.
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResourceAttributes `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics     `json:"scopeMetrics"`
+}
+
+type otlpResourceAttributes struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64         `json:"startTimeUnixNano,string,omitempty"`
+	TimeUnixNano      uint64         `json:"timeUnixNano,string"`
+	AsDouble          otlpDouble     `json:"asDouble"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64         `json:"startTimeUnixNano,string,omitempty"`
+	TimeUnixNano      uint64         `json:"timeUnixNano,string"`
+	Count             uint64         `json:"count,string"`
+	Sum               otlpDouble     `json:"sum"`
+	BucketCounts      []otlpUint64   `json:"bucketCounts"`
+	ExplicitBounds    []otlpDouble   `json:"explicitBounds"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func otlpAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+// otlpDouble encodes NaN and infinities the way the protobuf JSON mapping
+// expects.
+type otlpDouble float64
+
+func (d otlpDouble) MarshalJSON() ([]byte, error) {
+	v := float64(d)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return strconv.AppendFloat(nil, v, 'g', -1, 64), nil
+}
+
+type otlpUint64 uint64
+
+func (u otlpUint64) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, strconv.FormatUint(uint64(u), 10)), nil
+}
+
+func (m otlpMetric) pointCount() int {
+	switch {
+	case m.Gauge != nil:
+		return len(m.Gauge.DataPoints)
+	case m.Sum != nil:
+		return len(m.Sum.DataPoints)
+	case m.Histogram != nil:
+		return len(m.Histogram.DataPoints)
+	}
+	return 0
+}
+
+func (r otlpRequest) marshalProto() []byte {
+	var b []byte
+	for _, rm := range r.ResourceMetrics {
+		var resource []byte
+		for _, a := range rm.Resource.Attributes {
+			resource = appendProtoBytes(resource, 1, a.marshalProto())
+		}
+
+		var msg []byte
+		msg = appendProtoBytes(msg, 1, resource)
+		for _, sm := range rm.ScopeMetrics {
+			var scope []byte
+			scope = appendProtoBytes(scope, 1, appendProtoString(nil, 1, sm.Scope.Name))
+			for _, m := range sm.Metrics {
+				scope = appendProtoBytes(scope, 2, m.marshalProto())
+			}
+			msg = appendProtoBytes(msg, 2, scope)
+		}
+		b = appendProtoBytes(b, 1, msg)
+	}
+	return b
+}
+
+func (m otlpMetric) marshalProto() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, m.Name)
+	b = appendProtoString(b, 2, m.Description)
+
+	switch {
+	case m.Gauge != nil:
+		var gauge []byte
+		for _, p := range m.Gauge.DataPoints {
+			gauge = appendProtoBytes(gauge, 1, p.marshalProto())
+		}
+		b = appendProtoBytes(b, 5, gauge)
+	case m.Sum != nil:
+		var sum []byte
+		for _, p := range m.Sum.DataPoints {
+			sum = appendProtoBytes(sum, 1, p.marshalProto())
+		}
+		sum = appendProtoVarint(sum, 2, uint64(m.Sum.AggregationTemporality))
+		if m.Sum.IsMonotonic {
+			sum = appendProtoVarint(sum, 3, 1)
+		}
+		b = appendProtoBytes(b, 7, sum)
+	case m.Histogram != nil:
+		var histogram []byte
+		for _, p := range m.Histogram.DataPoints {
+			histogram = appendProtoBytes(histogram, 1, p.marshalProto())
+		}
+		histogram = appendProtoVarint(histogram, 2, uint64(m.Histogram.AggregationTemporality))
+		b = appendProtoBytes(b, 9, histogram)
+	}
+	return b
+}
+
+func (p otlpNumberPoint) marshalProto() []byte {
+	var b []byte
+	if p.StartTimeUnixNano != 0 {
+		b = appendProtoFixed64(b, 2, p.StartTimeUnixNano)
+	}
+	b = appendProtoFixed64(b, 3, p.TimeUnixNano)
+	b = appendProtoDouble(b, 4, float64(p.AsDouble))
+	for _, a := range p.Attributes {
+		b = appendProtoBytes(b, 7, a.marshalProto())
+	}
+	return b
+}
+
+func (p otlpHistogramPoint) marshalProto() []byte {
+	var b []byte
+	if p.StartTimeUnixNano != 0 {
+		b = appendProtoFixed64(b, 2, p.StartTimeUnixNano)
+	}
+	b = appendProtoFixed64(b, 3, p.TimeUnixNano)
+	b = appendProtoFixed64(b, 4, p.Count)
+	b = appendProtoDouble(b, 5, float64(p.Sum))
+
+	// Repeated scalars are packed.
+	var counts, bounds []byte
+	for _, c := range p.BucketCounts {
+		counts = binary.LittleEndian.AppendUint64(counts, uint64(c))
+	}
+	for _, bound := range p.ExplicitBounds {
+		bounds = binary.LittleEndian.AppendUint64(bounds, math.Float64bits(float64(bound)))
+	}
+	b = appendProtoBytes(b, 6, counts)
+	b = appendProtoBytes(b, 7, bounds)
+
+	for _, a := range p.Attributes {
+		b = appendProtoBytes(b, 9, a.marshalProto())
+	}
+	return b
+}
+
+func (kv otlpKeyValue) marshalProto() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, kv.Key)
+	return appendProtoBytes(b, 2, appendProtoString(nil, 1, kv.Value.StringValue))
+}
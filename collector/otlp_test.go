@@ -0,0 +1,180 @@
+package collector
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+var otlpTestMetrics = map[string]float64{
+	`cpu_usage_percent`:                                12.5,
+	`interrupts_total{irq="1",cpu="0"}`:                100,
+	`log_match_value_bucket{pattern="slow",le="1"}`:    2,
+	`log_match_value_bucket{pattern="slow",le="+Inf"}`: 5,
+	`log_match_value_count{pattern="slow"}`:            5,
+	`log_match_value_sum{pattern="slow"}`:              7.5,
+}
+
+// protoChildren returns the fields numbered num of a message.
+func protoChildren(t *testing.T, msg []byte, num int) []protoField {
+	t.Helper()
+	var out []protoField
+	for _, f := range mustDecodeProto(t, msg) {
+		if f.num == num {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestOTLPProtobufEncoding(t *testing.T) {
+	boot := time.Unix(1600000000, 0)
+	started := time.Unix(1700000000, 0)
+	now := time.Unix(1700000060, 0)
+	start := otlpStartTimes{process: started, boot: boot}
+
+	request := otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResourceAttributes{Attributes: []otlpKeyValue{otlpAttribute("host.name", "web1")}},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlpScope{Name: "system-monitoring"},
+			Metrics: buildOTLPMetrics(parseBatch(otlpTestMetrics), start, now),
+		}},
+	}}}
+	body := request.marshalProto()
+
+	// ExportMetricsServiceRequest.resource_metrics
+	rm := protoChildren(t, body, 1)
+	if len(rm) != 1 {
+		t.Fatalf("%d resource metrics, want 1", len(rm))
+	}
+	resource := protoChildren(t, rm[0].bytes, 1)[0].bytes
+	kv := protoChildren(t, resource, 1)[0].bytes
+	if key := protoChildren(t, kv, 1)[0].bytes; string(key) != "host.name" {
+		t.Errorf("resource attribute %q", key)
+	}
+	value := protoChildren(t, protoChildren(t, kv, 2)[0].bytes, 1)[0].bytes
+	if string(value) != "web1" {
+		t.Errorf("resource attribute value %q", value)
+	}
+
+	scope := protoChildren(t, rm[0].bytes, 2)[0].bytes
+	if name := protoChildren(t, protoChildren(t, scope, 1)[0].bytes, 1)[0].bytes; string(name) != "system-monitoring" {
+		t.Errorf("scope name %q", name)
+	}
+	metrics := protoChildren(t, scope, 2)
+	if len(metrics) != 3 {
+		t.Fatalf("%d metrics, want 3", len(metrics))
+	}
+
+	// Gauge
+	m := metrics[0].bytes
+	if name := protoChildren(t, m, 1)[0].bytes; string(name) != "cpu_usage_percent" {
+		t.Errorf("metric 0 is %q", name)
+	}
+	point := protoChildren(t, protoChildren(t, m, 5)[0].bytes, 1)[0].bytes
+	if len(protoChildren(t, point, 2)) != 0 {
+		t.Error("gauge point has a start time")
+	}
+	if got := protoChildren(t, point, 3)[0].value; got != uint64(now.UnixNano()) {
+		t.Errorf("gauge time = %d", got)
+	}
+	if got := protoChildren(t, point, 4)[0].double(); got != 12.5 {
+		t.Errorf("gauge value = %v", got)
+	}
+
+	// Sum of a kernel counter: the suffix is dropped and it starts at boot.
+	m = metrics[1].bytes
+	if name := protoChildren(t, m, 1)[0].bytes; string(name) != "interrupts" {
+		t.Errorf("metric 1 is %q", name)
+	}
+	sum := protoChildren(t, m, 7)[0].bytes
+	if got := protoChildren(t, sum, 2)[0].value; got != otlpTemporalityCumulative {
+		t.Errorf("temporality = %d", got)
+	}
+	if got := protoChildren(t, sum, 3)[0].value; got != 1 {
+		t.Errorf("is_monotonic = %d", got)
+	}
+	point = protoChildren(t, sum, 1)[0].bytes
+	if got := protoChildren(t, point, 2)[0].value; got != uint64(boot.UnixNano()) {
+		t.Errorf("counter start = %d, want boot time", got)
+	}
+	if attrs := protoChildren(t, point, 7); len(attrs) != 2 {
+		t.Errorf("%d counter attributes, want 2", len(attrs))
+	}
+
+	// Histogram of a process counter: it starts with the process, and bucket
+	// counts are no longer cumulative.
+	m = metrics[2].bytes
+	if name := protoChildren(t, m, 1)[0].bytes; string(name) != "log_match_value" {
+		t.Errorf("metric 2 is %q", name)
+	}
+	point = protoChildren(t, protoChildren(t, m, 9)[0].bytes, 1)[0].bytes
+	if got := protoChildren(t, point, 2)[0].value; got != uint64(started.UnixNano()) {
+		t.Errorf("histogram start = %d, want process start", got)
+	}
+	if got := protoChildren(t, point, 4)[0].value; got != 5 {
+		t.Errorf("histogram count = %d", got)
+	}
+	if got := protoChildren(t, point, 5)[0].double(); got != 7.5 {
+		t.Errorf("histogram sum = %v", got)
+	}
+	counts := protoChildren(t, point, 6)[0].bytes
+	if len(counts) != 16 || binary.LittleEndian.Uint64(counts) != 2 || binary.LittleEndian.Uint64(counts[8:]) != 3 {
+		t.Errorf("packed bucket counts % x, want 2 and 3", counts)
+	}
+	bounds := protoChildren(t, point, 7)[0].bytes
+	if len(bounds) != 8 || math.Float64frombits(binary.LittleEndian.Uint64(bounds)) != 1 {
+		t.Errorf("packed bounds % x, want 1", bounds)
+	}
+}
+
+func TestOTLPExporterJSON(t *testing.T) {
+	var body map[string]any
+	var contentType, custom string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("invalid JSON: %v", err)
+		}
+		contentType, custom = r.Header.Get("Content-Type"), r.Header.Get("X-Scope")
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(models.OTLPConfig{
+		Endpoint:    server.URL,
+		Protocol:    "http/json",
+		Headers:     map[string]string{"X-Scope": "tenant"},
+		ServiceName: "sysmon",
+	}, models.DatabaseConfig{}, nil, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.SendMetrics(context.Background(), otlpTestMetrics, time.Unix(1700000060, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if contentType != "application/json" || custom != "tenant" {
+		t.Errorf("headers %q, %q", contentType, custom)
+	}
+	rm := body["resourceMetrics"].([]any)[0].(map[string]any)
+	metrics := rm["scopeMetrics"].([]any)[0].(map[string]any)["metrics"].([]any)
+	sum := metrics[1].(map[string]any)["sum"].(map[string]any)
+	point := sum["dataPoints"].([]any)[0].(map[string]any)
+	// 64 bit integers are strings in the protobuf JSON mapping.
+	if point["timeUnixNano"] != "1700000060000000000" || point["asDouble"] != 100.0 {
+		t.Errorf("sum point %v", point)
+	}
+	histogram := metrics[2].(map[string]any)["histogram"].(map[string]any)
+	hp := histogram["dataPoints"].([]any)[0].(map[string]any)
+	if hp["count"] != "5" || len(hp["bucketCounts"].([]any)) != 2 {
+		t.Errorf("histogram point %v", hp)
+	}
+}
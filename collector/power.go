@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+type raplReading struct {
+	energyUJ int64
+	at       time.Time
+}
+
+// raplState keeps the previous energy counter of every zone so that power can
+// be derived between collection cycles.
+var raplState = struct {
+	sync.Mutex
+	last map[string]raplReading
+}{last: make(map[string]raplReading)}
+
+func GetPowerStats(sysPath string, logger *slog.Logger) (*models.PowerStats, error) {
+	supplies, err := readPowerSupplies(filepath.Join(sysPath, "class", "power_supply"))
+	if err != nil {
+		return nil, err
+	}
+
+	zones, err := readRAPLZones(filepath.Join(sysPath, "class", "powercap"), logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PowerStats{
+		Supplies:  supplies,
+		RAPLZones: zones,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+func readPowerSupplies(path string) ([]models.PowerSupplyStats, error) {
+	entries, err := os.ReadDir(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var supplies []models.PowerSupplyStats
+	for _, entry := range entries {
+		dir := filepath.Join(path, entry.Name())
+		supply := models.PowerSupplyStats{
+			Name:   entry.Name(),
+			Type:   readSysfsString(filepath.Join(dir, "type")),
+			Status: readSysfsString(filepath.Join(dir, "status")),
+		}
+
+		if online, err := readSysfsInt(filepath.Join(dir, "online")); err == nil {
+			value := online == 1
+			supply.Online = &value
+		}
+		if capacity, err := readSysfsInt(filepath.Join(dir, "capacity")); err == nil {
+			value := float64(capacity)
+			supply.CapacityPct = &value
+		}
+		if microvolts, err := readSysfsInt(filepath.Join(dir, "voltage_now")); err == nil {
+			value := float64(microvolts) / 1e6
+			supply.VoltageVolts = &value
+		}
+		if cycles, err := readSysfsInt(filepath.Join(dir, "cycle_count")); err == nil {
+			supply.CycleCount = &cycles
+		}
+
+		supplies = append(supplies, supply)
+	}
+
+	return supplies, nil
+}
+
+// readRAPLZones reads the intel-rapl energy counters and converts the change
+// since the previous cycle into watts. Zones whose counter is not readable,
+// which is the default for non-root users on recent kernels, are skipped.
+func readRAPLZones(path string, logger *slog.Logger) ([]models.RAPLZoneStats, error) {
+	dirs, err := filepath.Glob(filepath.Join(path, "intel-rapl:*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+
+	raplState.Lock()
+	defer raplState.Unlock()
+
+	now := time.Now()
+	var zones []models.RAPLZoneStats
+	for _, dir := range dirs {
+		zone := filepath.Base(dir)
+
+		energy, err := readSysfsInt(filepath.Join(dir, "energy_uj"))
+		if err != nil {
+			logger.Debug("Skipping RAPL zone", "zone", zone, "error", err)
+			continue
+		}
+
+		stats := models.RAPLZoneStats{
+			Zone:         zone,
+			Name:         readSysfsString(filepath.Join(dir, "name")),
+			EnergyJoules: float64(energy) / 1e6,
+		}
+
+		if prev, ok := raplState.last[zone]; ok && now.After(prev.at) {
+			delta := energy - prev.energyUJ
+			if delta < 0 {
+				// The counter wrapped: max_energy_range_uj is its largest
+				// value, so it counts modulo max_energy_range_uj + 1.
+				maxRange, err := readSysfsInt(filepath.Join(dir, "max_energy_range_uj"))
+				if err == nil {
+					delta += maxRange + 1
+				}
+			}
+			if delta >= 0 {
+				stats.PowerWatts = float64(delta) / 1e6 / now.Sub(prev.at).Seconds()
+				stats.HasPower = true
+			}
+		}
+		raplState.last[zone] = raplReading{energyUJ: energy, at: now}
+
+		zones = append(zones, stats)
+	}
+
+	return zones, nil
+}
@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// resetRAPLState forgets the previous RAPL readings, now and after the test.
+func resetRAPLState(t *testing.T) {
+	reset := func() {
+		raplState.Lock()
+		raplState.last = make(map[string]raplReading)
+		raplState.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestGetPowerStatsFixture(t *testing.T) {
+	resetRAPLState(t)
+	sys := t.TempDir()
+	writeTree(t, sys, map[string]string{
+		"class/power_supply/AC/type":                      "Mains\n",
+		"class/power_supply/AC/online":                    "1\n",
+		"class/power_supply/BAT0/type":                    "Battery\n",
+		"class/power_supply/BAT0/status":                  "Discharging\n",
+		"class/power_supply/BAT0/capacity":                "87\n",
+		"class/power_supply/BAT0/voltage_now":             "12600000\n",
+		"class/power_supply/BAT0/cycle_count":             "42\n",
+		"class/powercap/intel-rapl:0/name":                "package-0\n",
+		"class/powercap/intel-rapl:0/energy_uj":           "999990\n",
+		"class/powercap/intel-rapl:0/max_energy_range_uj": "999999\n",
+	})
+
+	stats, err := GetPowerStats(sys, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Supplies) != 2 {
+		t.Fatalf("got %d supplies, want 2", len(stats.Supplies))
+	}
+	ac, bat := stats.Supplies[0], stats.Supplies[1]
+	if ac.Name != "AC" || ac.Online == nil || !*ac.Online || ac.CapacityPct != nil {
+		t.Errorf("unexpected AC supply %+v", ac)
+	}
+	if bat.Status != "Discharging" || *bat.CapacityPct != 87 || *bat.VoltageVolts != 12.6 || *bat.CycleCount != 42 {
+		t.Errorf("unexpected battery %+v", bat)
+	}
+
+	if len(stats.RAPLZones) != 1 || stats.RAPLZones[0].HasPower {
+		t.Fatalf("first cycle should have energy but no power: %+v", stats.RAPLZones)
+	}
+	if got := stats.RAPLZones[0].EnergyJoules; got != 0.99999 {
+		t.Errorf("energy = %v J, want 0.99999", got)
+	}
+
+	// Wrap the counter: from 999990 it counts 999999, 0, ... 5, which is 15
+	// microjoules. Backdate the previous reading to get a known interval.
+	raplState.Lock()
+	prev := raplState.last["intel-rapl:0"]
+	prev.at = prev.at.Add(-time.Second)
+	raplState.last["intel-rapl:0"] = prev
+	raplState.Unlock()
+	if err := os.WriteFile(filepath.Join(sys, "class/powercap/intel-rapl:0/energy_uj"), []byte("5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err = GetPowerStats(sys, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	zone := stats.RAPLZones[0]
+	if !zone.HasPower {
+		t.Fatal("no power after the second cycle")
+	}
+	if want := 15e-6; math.Abs(zone.PowerWatts-want) > want*0.02 {
+		t.Errorf("power = %v W, want about %v", zone.PowerWatts, want)
+	}
+}
@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	prometheusTextContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsTextContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// PrometheusExporter is a sink that keeps the latest collected samples and
+// serves them to Prometheus scrapers instead of pushing them anywhere.
+type PrometheusExporter struct {
+	server *http.Server
+	logger *slog.Logger
+
+	mu        sync.RWMutex
+	metrics   map[string]float64
+	timestamp time.Time
+}
+
+// NewPrometheusExporter starts serving the scrape endpoint on listenAddress.
+func NewPrometheusExporter(listenAddress, path string, logger *slog.Logger) (*PrometheusExporter, error) {
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", listenAddress, err)
+	}
+
+	p := &PrometheusExporter{logger: logger}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, p.handleMetrics)
+	p.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := p.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Prometheus endpoint stopped", "error", err)
+		}
+	}()
+
+	logger.Info("Serving Prometheus metrics", "address", listener.Addr().String(), "path", path)
+	return p, nil
+}
+
+func (p *PrometheusExporter) Name() string {
+	return "prometheus"
+}
+
+func (p *PrometheusExporter) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (p *PrometheusExporter) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	latest := make(map[string]float64, len(metrics))
+	for k, v := range metrics {
+		latest[k] = v
+	}
+
+	p.mu.Lock()
+	p.metrics = latest
+	p.timestamp = timestamp
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *PrometheusExporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.server.Shutdown(ctx)
+}
+
+func (p *PrometheusExporter) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	metrics := p.metrics
+	p.mu.RUnlock()
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+
+	var body []byte
+	if openMetrics {
+		w.Header().Set("Content-Type", openMetricsTextContentType)
+		body = formatExposition(metrics, true)
+	} else {
+		w.Header().Set("Content-Type", prometheusTextContentType)
+		body = formatExposition(metrics, false)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		p.logger.Debug("Failed to write scrape response", "error", err)
+	}
+}
+
+// formatExposition renders samples in the Prometheus text format, or in the
+// OpenMetrics text format, where counter families drop the _total suffix and
+// the output ends with # EOF.
+func formatExposition(metrics map[string]float64, openMetrics bool) []byte {
+	var buf bytes.Buffer
+	family := ""
+
+	for _, s := range parseBatch(metrics) {
+		if s.Family.Name != family {
+			family = s.Family.Name
+			name := family
+			if openMetrics && s.Family.Type == "counter" {
+				name = strings.TrimSuffix(name, "_total")
+			}
+			fmt.Fprintf(&buf, "# HELP %s %s\n", name, metricHelp(family))
+			fmt.Fprintf(&buf, "# TYPE %s %s\n", name, s.Family.Type)
+		}
+		buf.WriteString(s.Key)
+		buf.WriteByte(' ')
+		buf.WriteString(formatSampleValue(s.Value))
+		buf.WriteByte('\n')
+	}
+
+	if openMetrics {
+		buf.WriteString("# EOF\n")
+	}
+	return buf.Bytes()
+}
+
+// metricHelp derives a description from the metric name, e.g.
+// "cpu usage percent" for cpu_usage_percent.
+func metricHelp(name string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(name, "_total"), "_", " ")
+}
+
+func formatSampleValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
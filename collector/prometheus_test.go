@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPrometheusExporterScrape(t *testing.T) {
+	p := &PrometheusExporter{logger: discardLogger()}
+	server := httptest.NewServer(http.HandlerFunc(p.handleMetrics))
+	defer server.Close()
+
+	metrics := map[string]float64{
+		"cpu_usage_percent": math.NaN(),
+		formatMetric("kernel_messages_total", map[string]string{"severity": "err"}): 3,
+		formatMetric("log_matches_total", map[string]string{
+			"file":    `C:\logs\app.log`,
+			"pattern": "say \"hi\"\nagain",
+		}): 2,
+		formatMetric("log_match_value_bucket", map[string]string{"pattern": "slow", "le": "0.5"}):  1,
+		formatMetric("log_match_value_bucket", map[string]string{"pattern": "slow", "le": "+Inf"}): 2,
+		formatMetric("log_match_value_sum", map[string]string{"pattern": "slow"}):                  1.25,
+		formatMetric("log_match_value_count", map[string]string{"pattern": "slow"}):                2,
+		"clock_offset_seconds":    math.Inf(-1),
+		"clock_max_error_seconds": math.Inf(1),
+		"node_boot_time_seconds":  1.7e9,
+	}
+	if err := p.SendMetrics(t.Context(), metrics, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	const samples = "# HELP clock_max_error_seconds clock max error seconds\n" +
+		"# TYPE clock_max_error_seconds gauge\n" +
+		"clock_max_error_seconds +Inf\n" +
+		"# HELP clock_offset_seconds clock offset seconds\n" +
+		"# TYPE clock_offset_seconds gauge\n" +
+		"clock_offset_seconds -Inf\n" +
+		"# HELP cpu_usage_percent cpu usage percent\n" +
+		"# TYPE cpu_usage_percent gauge\n" +
+		"cpu_usage_percent NaN\n"
+	const rest = "# TYPE log_match_value histogram\n" +
+		"log_match_value_bucket{le=\"0.5\",pattern=\"slow\"} 1\n" +
+		"log_match_value_bucket{le=\"+Inf\",pattern=\"slow\"} 2\n" +
+		"log_match_value_count{pattern=\"slow\"} 2\n" +
+		"log_match_value_sum{pattern=\"slow\"} 1.25\n"
+	const escaped = `log_matches_total{file="C:\\logs\\app.log",pattern="say \"hi\"\nagain"} 2` + "\n"
+
+	tests := []struct {
+		accept      string
+		contentType string
+		want        string
+	}{
+		{
+			accept:      "",
+			contentType: prometheusTextContentType,
+			want: samples +
+				"# HELP kernel_messages_total kernel messages\n" +
+				"# TYPE kernel_messages_total counter\n" +
+				"kernel_messages_total{severity=\"err\"} 3\n" +
+				"# HELP log_match_value log match value\n" + rest +
+				"# HELP log_matches_total log matches\n" +
+				"# TYPE log_matches_total counter\n" + escaped +
+				"# HELP node_boot_time_seconds node boot time seconds\n" +
+				"# TYPE node_boot_time_seconds gauge\n" +
+				"node_boot_time_seconds 1.7e+09\n",
+		},
+		{
+			accept:      "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5",
+			contentType: openMetricsTextContentType,
+			want: samples +
+				"# HELP kernel_messages kernel messages\n" +
+				"# TYPE kernel_messages counter\n" +
+				"kernel_messages_total{severity=\"err\"} 3\n" +
+				"# HELP log_match_value log match value\n" + rest +
+				"# HELP log_matches log matches\n" +
+				"# TYPE log_matches counter\n" + escaped +
+				"# HELP node_boot_time_seconds node boot time seconds\n" +
+				"# TYPE node_boot_time_seconds gauge\n" +
+				"node_boot_time_seconds 1.7e+09\n" +
+				"# EOF\n",
+		},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: content type %q, want %q", tt.accept, got, tt.contentType)
+		}
+		if string(body) != tt.want {
+			t.Errorf("Accept %q: body\n%s\nwant\n%s", tt.accept, body, tt.want)
+		}
+	}
+}
+
+func TestPrometheusExporterEmpty(t *testing.T) {
+	p := &PrometheusExporter{logger: discardLogger()}
+	rec := httptest.NewRecorder()
+	p.handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("before the first cycle: %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Minimal protobuf wire format encoding for the push protocols that use it.
+// Fields are appended in field number order; zero values are written as given.
+
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+func appendProtoTag(b []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+func appendProtoBytes(b []byte, field int, data []byte) []byte {
+	b = appendProtoTag(b, field, protoBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendProtoString(b []byte, field int, s string) []byte {
+	b = appendProtoTag(b, field, protoBytes)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	b = appendProtoTag(b, field, protoVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func appendProtoFixed64(b []byte, field int, v uint64) []byte {
+	b = appendProtoTag(b, field, protoFixed64)
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+func appendProtoDouble(b []byte, field int, v float64) []byte {
+	return appendProtoFixed64(b, field, math.Float64bits(v))
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// protoField is one decoded field of a protobuf message.
+type protoField struct {
+	num   int
+	wire  int
+	value uint64 // varint and fixed64 fields
+	bytes []byte // length delimited fields
+}
+
+func (f protoField) double() float64 { return math.Float64frombits(f.value) }
+
+// decodeProto splits a message into its fields, independently of the
+// encoding helpers, so that tests can check what they produce.
+func decodeProto(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("bad tag")
+		}
+		b = b[n:]
+		f := protoField{num: int(tag >> 3), wire: int(tag & 7)}
+		switch f.wire {
+		case protoVarint:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				return nil, fmt.Errorf("bad varint in field %d", f.num)
+			}
+			b = b[n:]
+		case protoFixed64:
+			if len(b) < 8 {
+				return nil, fmt.Errorf("short fixed64 in field %d", f.num)
+			}
+			f.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case protoBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return nil, fmt.Errorf("bad length in field %d", f.num)
+			}
+			f.bytes = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			return nil, fmt.Errorf("unexpected wire type %d in field %d", f.wire, f.num)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// mustDecodeProto decodes b and fails the test on malformed input.
+func mustDecodeProto(t *testing.T, b []byte) []protoField {
+	t.Helper()
+	fields, err := decodeProto(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fields
+}
+
+func TestProtoEncoding(t *testing.T) {
+	var b []byte
+	b = appendProtoVarint(b, 1, 300)
+	b = appendProtoString(b, 2, "testing")
+	b = appendProtoDouble(b, 3, -1.5)
+	b = appendProtoBytes(b, 20, []byte{1, 2})
+
+	// Reference encoding from the protobuf documentation: field 1 varint 300
+	// is 08 ac 02, field 2 "testing" is 12 07 74 65 73 74 69 6e 67.
+	golden := []byte{0x08, 0xac, 0x02, 0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g'}
+	if string(b[:len(golden)]) != string(golden) {
+		t.Errorf("encoded % x, want prefix % x", b[:len(golden)], golden)
+	}
+
+	fields := mustDecodeProto(t, b)
+	if len(fields) != 4 {
+		t.Fatalf("decoded %d fields, want 4", len(fields))
+	}
+	if fields[2].num != 3 || fields[2].double() != -1.5 {
+		t.Errorf("double field = %+v", fields[2])
+	}
+	if fields[3].num != 20 || string(fields[3].bytes) != "\x01\x02" {
+		t.Errorf("bytes field = %+v", fields[3])
+	}
+}
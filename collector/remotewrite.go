@@ -0,0 +1,181 @@
+package collector
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// RemoteWriteClient pushes metrics using the Prometheus remote write protocol
+// (version 1.0: snappy compressed protobuf WriteRequest), as accepted by
+// VictoriaMetrics' /api/v1/write, Mimir, Thanos receive and Prometheus itself.
+type RemoteWriteClient struct {
+	config   models.RemoteWriteConfig
+	delivery *httpDelivery
+	logger   *slog.Logger
+}
+
+func NewRemoteWriteClient(config models.RemoteWriteConfig, database models.DatabaseConfig, logger *slog.Logger) *RemoteWriteClient {
+	return &RemoteWriteClient{
+		config:   config,
+		delivery: newHTTPDelivery(database, logger),
+		logger:   logger,
+	}
+}
+
+func (r *RemoteWriteClient) Name() string {
+	return "remote_write"
+}
+
+// Ping always succeeds: the protocol defines no health endpoint, so an
+// unreachable receiver only shows up when sending.
+func (r *RemoteWriteClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (r *RemoteWriteClient) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	if len(metrics) == 0 {
+		r.logger.DebugContext(ctx, "No metrics to send")
+		return nil
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-protobuf")
+	header.Set("Content-Encoding", "snappy")
+	header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	switch {
+	case r.config.BearerToken != "":
+		header.Set("Authorization", "Bearer "+r.config.BearerToken)
+	case r.config.Username != "":
+		header.Set("Authorization", basicAuth(r.config.Username, r.config.Password))
+	}
+
+	for _, batch := range r.delivery.batches(metrics) {
+		body := encodeWriteRequest(batch, metrics, timestamp.UnixMilli(), r.logger)
+
+		r.logger.DebugContext(ctx, "Sending metrics via remote write",
+			"count", len(batch),
+			"bytes", len(body),
+			"url", r.config.URL)
+
+		if err := r.delivery.post(ctx, r.config.URL, header, snappyEncode(body)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RemoteWriteClient) Close() error {
+	r.delivery.client.CloseIdleConnections()
+	return nil
+}
+
+// encodeWriteRequest marshals the series as a prometheus.WriteRequest:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(keys []string, metrics map[string]float64, timestampMs int64, logger *slog.Logger) []byte {
+	var buf, series, sample []byte
+
+	for _, key := range keys {
+		name, labels, err := parseSeries(key)
+		if err != nil {
+			logger.Debug("Skipping series", "series", key, "error", err)
+			continue
+		}
+		labels = append([]seriesLabel{{Name: "__name__", Value: name}}, labels...)
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+		series = series[:0]
+		for _, l := range labels {
+			var label []byte
+			label = appendProtoString(label, 1, l.Name)
+			label = appendProtoString(label, 2, l.Value)
+			series = appendProtoBytes(series, 1, label)
+		}
+
+		sample = sample[:0]
+		sample = appendProtoDouble(sample, 1, metrics[key])
+		sample = appendProtoVarint(sample, 2, uint64(timestampMs))
+		series = appendProtoBytes(series, 2, sample)
+
+		buf = appendProtoBytes(buf, 1, series)
+	}
+	return buf
+}
+
+// snappyEncode compresses src in the snappy block format. Input is split into
+// 64 KiB blocks like the reference encoder, so that every back reference fits
+// a two byte offset.
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		n := min(len(src), 1<<16)
+		dst = snappyEncodeBlock(dst, src[:n])
+		src = src[n:]
+	}
+	return dst
+}
+
+func snappyEncodeBlock(dst, src []byte) []byte {
+	const tableBits = 14
+	// Positions of recently seen 4 byte sequences, stored off by one so that
+	// zero means empty.
+	var table [1 << tableBits]int32
+
+	literal := 0
+	for i := 0; i+4 <= len(src); {
+		v := binary.LittleEndian.Uint32(src[i:])
+		h := (v * 0x1e35a7bd) >> (32 - tableBits)
+		candidate := int(table[h]) - 1
+		table[h] = int32(i + 1)
+
+		if candidate < 0 || binary.LittleEndian.Uint32(src[candidate:]) != v {
+			i++
+			continue
+		}
+
+		dst = snappyLiteral(dst, src[literal:i])
+		length := 4
+		for i+length < len(src) && src[candidate+length] == src[i+length] {
+			length++
+		}
+		dst = snappyCopy(dst, i-candidate, length)
+		i += length
+		literal = i
+	}
+	return snappyLiteral(dst, src[literal:])
+}
+
+func snappyLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	switch n := len(lit) - 1; {
+	case n < 60:
+		dst = append(dst, byte(n<<2))
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	default:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	}
+	return append(dst, lit...)
+}
+
+// snappyCopy emits a back reference as copies with a two byte offset, each of
+// at most 64 bytes.
+func snappyCopy(dst []byte, offset, length int) []byte {
+	for length > 0 {
+		n := min(length, 64)
+		dst = append(dst, byte((n-1)<<2|2), byte(offset), byte(offset>>8))
+		length -= n
+	}
+	return dst
+}
@@ -0,0 +1,225 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// snappyDecode is a straightforward decoder of the snappy block format,
+// written from the format description, used as reference for the encoder.
+func snappyDecode(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 {
+		return nil, errors.New("bad length")
+	}
+	src = src[n:]
+	dst := make([]byte, 0, length)
+
+	for len(src) > 0 {
+		tag := src[0]
+		src = src[1:]
+		switch tag & 3 {
+		case 0:
+			size := int(tag >> 2)
+			if size >= 60 {
+				extra := size - 59
+				if len(src) < extra {
+					return nil, errors.New("short literal length")
+				}
+				size = 0
+				for i := extra - 1; i >= 0; i-- {
+					size = size<<8 | int(src[i])
+				}
+				src = src[extra:]
+			}
+			size++
+			if len(src) < size {
+				return nil, errors.New("short literal")
+			}
+			dst = append(dst, src[:size]...)
+			src = src[size:]
+			continue
+		case 1:
+			if len(src) < 1 {
+				return nil, errors.New("short copy")
+			}
+			size := 4 + int(tag>>2&7)
+			offset := int(tag&0xe0)<<3 | int(src[0])
+			src = src[1:]
+			if err := snappyBackref(&dst, offset, size); err != nil {
+				return nil, err
+			}
+		case 2:
+			if len(src) < 2 {
+				return nil, errors.New("short copy")
+			}
+			offset := int(binary.LittleEndian.Uint16(src))
+			src = src[2:]
+			if err := snappyBackref(&dst, offset, 1+int(tag>>2)); err != nil {
+				return nil, err
+			}
+		case 3:
+			if len(src) < 4 {
+				return nil, errors.New("short copy")
+			}
+			offset := int(binary.LittleEndian.Uint32(src))
+			src = src[4:]
+			if err := snappyBackref(&dst, offset, 1+int(tag>>2)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if uint64(len(dst)) != length {
+		return nil, errors.New("length mismatch")
+	}
+	return dst, nil
+}
+
+func snappyBackref(dst *[]byte, offset, size int) error {
+	if offset <= 0 || offset > len(*dst) {
+		return errors.New("bad copy offset")
+	}
+	// Byte by byte, since a copy may overlap its own output.
+	for i := 0; i < size; i++ {
+		*dst = append(*dst, (*dst)[len(*dst)-offset])
+	}
+	return nil
+}
+
+func TestSnappyRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, 200000)
+	rng.Read(random)
+
+	inputs := map[string][]byte{
+		"empty":        nil,
+		"short":        []byte("abc"),
+		"repetitive":   bytes.Repeat([]byte("cpu_usage_percent{cpu=\"0\"} "), 5000),
+		"run":          bytes.Repeat([]byte{'a'}, 70000),
+		"random":       random,
+		"long literal": append(random[:300:300], bytes.Repeat([]byte("xyzw"), 100)...),
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			encoded := snappyEncode(input)
+			decoded, err := snappyDecode(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, input) {
+				t.Fatal("round trip changed the data")
+			}
+			if name == "repetitive" && len(encoded) > len(input)/10 {
+				t.Errorf("compressed %d bytes to %d", len(input), len(encoded))
+			}
+		})
+	}
+}
+
+func FuzzSnappyRoundTrip(f *testing.F) {
+	f.Add([]byte("hello hello hello hello"))
+	f.Add(bytes.Repeat([]byte{0}, 1<<16+10))
+	f.Fuzz(func(t *testing.T, input []byte) {
+		decoded, err := snappyDecode(snappyEncode(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Fatal("round trip changed the data")
+		}
+	})
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	metrics := map[string]float64{
+		`cpu_usage_percent`:                        12.5,
+		`disk_used_bytes{mount="/",device="sda1"}`: 1e9,
+	}
+	keys := []string{`cpu_usage_percent`, `disk_used_bytes{mount="/",device="sda1"}`}
+	body := encodeWriteRequest(keys, metrics, 1700000000123, discardLogger())
+
+	var got []string
+	for _, ts := range mustDecodeProto(t, body) {
+		if ts.num != 1 {
+			t.Fatalf("unexpected WriteRequest field %d", ts.num)
+		}
+		var labels []string
+		var samples int
+		for _, f := range mustDecodeProto(t, ts.bytes) {
+			switch f.num {
+			case 1:
+				label := mustDecodeProto(t, f.bytes)
+				labels = append(labels, string(label[0].bytes)+"="+string(label[1].bytes))
+			case 2:
+				sample := mustDecodeProto(t, f.bytes)
+				if sample[1].value != 1700000000123 {
+					t.Errorf("timestamp = %d", sample[1].value)
+				}
+				labels = append(labels, formatSampleValue(sample[0].double()))
+				samples++
+			}
+		}
+		if samples != 1 {
+			t.Errorf("%d samples in a series, want 1", samples)
+		}
+		got = append(got, strings.Join(labels, " "))
+	}
+
+	// Labels are sorted by name, with __name__ first.
+	want := []string{
+		"__name__=cpu_usage_percent 12.5",
+		"__name__=disk_used_bytes device=sda1 mount=/ 1e+09",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("decoded series:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRemoteWriteClientSend(t *testing.T) {
+	var requests [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		decoded, err := snappyDecode(body)
+		if err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, decoded)
+		if len(requests) == 1 {
+			// Retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewRemoteWriteClient(
+		models.RemoteWriteConfig{URL: server.URL, BearerToken: "secret"},
+		models.DatabaseConfig{MaxRetries: 2, RetryBackoff: time.Millisecond},
+		discardLogger(),
+	)
+	err := client.SendMetrics(context.Background(), map[string]float64{"a": 1, "b": 2}, time.UnixMilli(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || !bytes.Equal(requests[0], requests[1]) {
+		t.Fatalf("got %d requests, want the same one twice", len(requests))
+	}
+	if series := mustDecodeProto(t, requests[1]); len(series) != 2 {
+		t.Errorf("%d series in one unbatched request, want 2", len(series))
+	}
+}
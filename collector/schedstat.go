@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// GetSchedStats reads the per-CPU run queue counters from /proc/schedstat.
+// Each "cpuN" line ends with the time tasks spent running, the time runnable
+// tasks spent waiting for this CPU (both in nanoseconds) and the number of
+// timeslices run.
+func GetSchedStats(procPath string) (*models.SchedStats, error) {
+	data, err := os.ReadFile(filepath.Join(procPath, "schedstat"))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.SchedStats{Timestamp: time.Now()}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		n := len(fields)
+		running, err := strconv.ParseUint(fields[n-3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		waiting, err := strconv.ParseUint(fields[n-2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		timeslices, err := strconv.ParseUint(fields[n-1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		stats.CPUs = append(stats.CPUs, models.SchedCPUStats{
+			CPU:        strings.TrimPrefix(fields[0], "cpu"),
+			Running:    time.Duration(running),
+			Waiting:    time.Duration(waiting),
+			Timeslices: timeslices,
+		})
+	}
+
+	if len(stats.CPUs) == 0 {
+		return nil, errors.New("could not find CPU lines in /proc/schedstat")
+	}
+	return stats, nil
+}
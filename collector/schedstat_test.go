@@ -0,0 +1,46 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+func TestGetSchedStatsFixture(t *testing.T) {
+	proc := t.TempDir()
+	writeTree(t, proc, map[string]string{
+		"schedstat": "version 15\n" +
+			"timestamp 4295532345\n" +
+			"cpu0 0 0 0 0 0 0 1234567890 2500000 1000\n" +
+			"domain0 00000003 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n" +
+			"cpu1 0 0 0 0 0 0 987654321 0 42\n",
+	})
+
+	stats, err := GetSchedStats(proc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.SchedCPUStats{
+		{CPU: "0", Running: 1234567890 * time.Nanosecond, Waiting: 2500 * time.Microsecond, Timeslices: 1000},
+		{CPU: "1", Running: 987654321 * time.Nanosecond, Timeslices: 42},
+	}
+	if len(stats.CPUs) != len(want) {
+		t.Fatalf("cpus = %+v, want %+v", stats.CPUs, want)
+	}
+	for i := range want {
+		if stats.CPUs[i] != want[i] {
+			t.Errorf("cpu %d = %+v, want %+v", i, stats.CPUs[i], want[i])
+		}
+	}
+}
+
+func TestGetSchedStatsNoCPUs(t *testing.T) {
+	proc := t.TempDir()
+	writeTree(t, proc, map[string]string{"schedstat": "version 15\ntimestamp 1\n"})
+
+	if _, err := GetSchedStats(proc); err == nil {
+		t.Error("expected an error without cpu lines")
+	}
+}
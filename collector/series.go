@@ -0,0 +1,250 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type seriesLabel struct {
+	Name  string
+	Value string
+}
+
+// parseSeries splits a series key produced by formatMetric back into its
+// metric name and labels.
+func parseSeries(key string) (string, []seriesLabel, error) {
+	open := strings.IndexByte(key, '{')
+	if open < 0 {
+		return key, nil, nil
+	}
+	if !strings.HasSuffix(key, "}") {
+		return "", nil, fmt.Errorf("malformed series %q", key)
+	}
+
+	name := key[:open]
+	rest := key[open+1 : len(key)-1]
+	var labels []seriesLabel
+
+	for rest != "" {
+		eq := strings.Index(rest, `="`)
+		if eq < 0 {
+			return "", nil, fmt.Errorf("malformed series %q", key)
+		}
+		label := seriesLabel{Name: rest[:eq]}
+		rest = rest[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(rest); i++ {
+			c := rest[i]
+			if c == '\\' && i+1 < len(rest) {
+				i++
+				switch rest[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(rest[i])
+				}
+				continue
+			}
+			if c == '"' {
+				rest = rest[i+1:]
+				closed = true
+				break
+			}
+			value.WriteByte(c)
+		}
+		if !closed {
+			return "", nil, fmt.Errorf("malformed series %q", key)
+		}
+		label.Value = value.String()
+		labels = append(labels, label)
+
+		rest = strings.TrimPrefix(rest, ",")
+	}
+
+	return name, labels, nil
+}
+
+// metricFamily groups a series under the family it is exposed as, together
+// with the family's type. Histogram parts share their base name; counters are
+// recognised by the _total suffix and everything else is a gauge.
+type metricFamily struct {
+	Name string
+	Type string // "counter", "gauge" or "histogram"
+}
+
+// familyOf returns the family of the metric name. histograms holds the base
+// names that have a _bucket series in the same batch.
+func familyOf(name string, histograms map[string]bool) metricFamily {
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if base := strings.TrimSuffix(name, suffix); base != name && histograms[base] {
+			return metricFamily{Name: base, Type: "histogram"}
+		}
+	}
+	if strings.HasSuffix(name, "_total") {
+		return metricFamily{Name: name, Type: "counter"}
+	}
+	return metricFamily{Name: name, Type: "gauge"}
+}
+
+type parsedSeries struct {
+	Key    string
+	Name   string
+	Labels []seriesLabel
+	Value  float64
+	Family metricFamily
+}
+
+// parseBatch parses every series of a batch, sorted by family and key, and
+// skips keys that cannot be parsed.
+func parseBatch(metrics map[string]float64) []parsedSeries {
+	series := make([]parsedSeries, 0, len(metrics))
+	histograms := make(map[string]bool)
+
+	for key, value := range metrics {
+		name, labels, err := parseSeries(key)
+		if err != nil {
+			continue
+		}
+		if base := strings.TrimSuffix(name, "_bucket"); base != name {
+			histograms[base] = true
+		}
+		series = append(series, parsedSeries{Key: key, Name: name, Labels: labels, Value: value})
+	}
+
+	for i := range series {
+		series[i].Family = familyOf(series[i].Name, histograms)
+	}
+	// Keep the parts of each histogram together, buckets first and in
+	// increasing order, as OpenMetrics requires.
+	sort.Slice(series, func(i, j int) bool {
+		a, b := series[i], series[j]
+		if a.Family.Name != b.Family.Name {
+			return a.Family.Name < b.Family.Name
+		}
+		if ka, kb := labelsKey(a.Labels), labelsKey(b.Labels); ka != kb {
+			return ka < kb
+		}
+		if pa, pb := histogramPart(a), histogramPart(b); pa != pb {
+			return pa < pb
+		}
+		return bucketBound(a.Labels) < bucketBound(b.Labels)
+	})
+	return series
+}
+
+// labelsKey renders the labels other than le for grouping.
+func labelsKey(labels []seriesLabel) string {
+	var b strings.Builder
+	for _, l := range labels {
+		if l.Name == "le" {
+			continue
+		}
+		b.WriteString(l.Name)
+		b.WriteByte('=')
+		b.WriteString(l.Value)
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+func histogramPart(s parsedSeries) int {
+	switch {
+	case s.Family.Type != "histogram":
+		return 0
+	case strings.HasSuffix(s.Name, "_bucket"):
+		return 0
+	case strings.HasSuffix(s.Name, "_count"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func bucketBound(labels []seriesLabel) float64 {
+	for _, l := range labels {
+		if l.Name == "le" {
+			bound, err := strconv.ParseFloat(l.Value, 64)
+			if err != nil {
+				return math.Inf(1)
+			}
+			return bound
+		}
+	}
+	return 0
+}
+
+// jsonSample is the JSON representation of a series used by the sinks that
+// write JSON documents. Timestamp is in milliseconds.
+type jsonSample struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     float64           `json:"value"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+}
+
+func newJSONSample(s parsedSeries) jsonSample {
+	sample := jsonSample{Name: s.Name, Value: s.Value}
+	if len(s.Labels) > 0 {
+		sample.Labels = make(map[string]string, len(s.Labels))
+		for _, l := range s.Labels {
+			sample.Labels[l.Name] = l.Value
+		}
+	}
+	return sample
+}
+
+// metricCollectors maps metric name prefixes to the collector that produces
+// them, for sinks that group series by collector. The mapping depends on the
+// name alone, so that a series lands in the same group whether it was just
+// collected or replayed from the buffer after a restart.
+var metricCollectors = []struct {
+	prefix    string
+	collector string
+}{
+	{"system_temperature_", "temperature"},
+	{"memory_", "memory"},
+	{"cpu_", "cpu"},
+	{"load_average_", "load"},
+	{"log_match", "logwatch"},
+	{"kernel_", "kmsg"},
+	{"md_", "storage"},
+	{"block_device_", "storage"},
+	{"node_", "inventory"},
+	{"clock_", "timex"},
+	{"power_supply_", "power"},
+	{"rapl_", "power"},
+	{"container", "containers"},
+	{"interrupts_", "interrupts"},
+	{"softirqs_", "interrupts"},
+	{"schedstat_", "schedstat"},
+	{"wireless_", "network_link"},
+	{"bonding_", "network_link"},
+	{"bridge_", "network_link"},
+	{"numa_", "numa"},
+	{"edac_", "edac"},
+	{"user_sessions_", "sessions"},
+	{"user_logins_", "sessions"},
+	{"listening_socket", "listeners"},
+	{"file_integrity_", "integrity"},
+	{"dir_", "dirwatch"},
+	{"file_newest_", "dirwatch"},
+	{"file_oldest_", "dirwatch"},
+	{"sink_buffer_", "buffer"},
+}
+
+// collectorOf returns the collector that produces the metric name, or the
+// name's first word for metrics of unknown origin.
+func collectorOf(name string) string {
+	for _, m := range metricCollectors {
+		if strings.HasPrefix(name, m.prefix) {
+			return m.collector
+		}
+	}
+	prefix, _, _ := strings.Cut(name, "_")
+	return prefix
+}
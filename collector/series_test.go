@@ -0,0 +1,143 @@
+package collector
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+func TestCollectorOf(t *testing.T) {
+	tests := map[string]string{
+		"node_info":                           "inventory",
+		"node_uptime_seconds":                 "inventory",
+		"cpu_usage_percent":                   "cpu",
+		"container_cpu_usage_seconds_total":   "containers",
+		"containers_running":                  "containers",
+		"clock_synced":                        "timex",
+		"clock_backend_offset_seconds":        "timex",
+		"md_array_degraded":                   "storage",
+		"block_device_size_bytes":             "storage",
+		"numa_hit_total":                      "numa",
+		"file_integrity_changes_total":        "integrity",
+		"file_newest_age_seconds":             "dirwatch",
+		"listening_sockets":                   "listeners",
+		"listening_socket_info":               "listeners",
+		"log_match_value_bucket":              "logwatch",
+		"sink_buffer_batches":                 "buffer",
+		"softirqs_total":                      "interrupts",
+		"bridge_port_forwarding":              "network_link",
+		"user_logins_total":                   "sessions",
+		"system_temperature_celsius":          "temperature",
+		"edac_csrow_correctable_errors_total": "edac",
+		"rapl_power_watts":                    "power",
+		// Unknown metrics fall back to their first word.
+		"custom_metric": "custom",
+	}
+	for name, want := range tests {
+		if got := collectorOf(name); got != want {
+			t.Errorf("collectorOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// populate sets every exported field reachable from v to a non-zero value, with
+// one element in each slice and map, so that converters emit all their series.
+func populate(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		populate(v.Elem())
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Now().Add(-time.Minute)))
+			return
+		}
+		for i := range v.NumField() {
+			if v.Field(i).CanSet() {
+				populate(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		populate(v.Index(0))
+	case reflect.Map:
+		key := reflect.New(v.Type().Key()).Elem()
+		elem := reflect.New(v.Type().Elem()).Elem()
+		populate(key)
+		populate(elem)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, elem)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	}
+}
+
+// TestMetricCollectorsCoverConversions checks that every series
+// convertToMetrics can emit is routed to its collector by the prefix table
+// rather than by the first-word fallback.
+func TestMetricCollectorsCoverConversions(t *testing.T) {
+	results := []models.ResultPtr{
+		{Type: "temperature", Data: &models.SystemTemp{}},
+		{Type: "memory", Data: &models.MemoryStats{}},
+		{Type: "cpu", Data: &models.CPUStats{}},
+		{Type: "load", Data: &models.LoadStats{}},
+		{Type: "logwatch", Data: &models.LogWatchStats{}},
+		{Type: "kmsg", Data: &models.KernelEventStats{}},
+		{Type: "storage", Data: &models.StorageHealthStats{}},
+		{Type: "inventory", Data: &models.InventoryStats{}},
+		{Type: "timex", Data: &models.TimexStats{}},
+		{Type: "power", Data: &models.PowerStats{}},
+		{Type: "containers", Data: &models.ContainersStats{}},
+		{Type: "interrupts", Data: &models.InterruptsStats{}},
+		{Type: "schedstat", Data: &models.SchedStats{}},
+		{Type: "network_link", Data: &models.NetworkLinkStats{}},
+		{Type: "numa", Data: &models.NUMAStats{}},
+		{Type: "edac", Data: &models.EDACStats{}},
+		{Type: "sessions", Data: &models.SessionStats{}},
+		{Type: "listeners", Data: &models.ListenersStats{}},
+		{Type: "integrity", Data: &models.IntegrityStats{}},
+		{Type: "dirwatch", Data: &models.DirWatchStats{}},
+	}
+
+	check := func(collector string, metrics map[string]float64) {
+		if len(metrics) == 0 {
+			t.Errorf("%s: no series converted", collector)
+		}
+		for key := range metrics {
+			name, _, err := parseSeries(key)
+			if err != nil {
+				t.Errorf("%s: %v", key, err)
+				continue
+			}
+			listed := slices.ContainsFunc(metricCollectors, func(m struct{ prefix, collector string }) bool {
+				return strings.HasPrefix(name, m.prefix)
+			})
+			if got := collectorOf(name); !listed || got != collector {
+				t.Errorf("%s is routed to %q (listed %v), want %q", name, got, listed, collector)
+			}
+		}
+	}
+
+	for _, result := range results {
+		populate(reflect.ValueOf(result.Data))
+		check(result.Type, convertToMetrics(result))
+	}
+
+	buffered := make(map[string]float64)
+	addBufferMetrics(buffered, &BufferedSink{Sink: &recordingSink{}, logger: discardLogger()})
+	check("buffer", buffered)
+}
@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// Layout of struct utmp as written by glibc on Linux, on both 32 and 64-bit
+// platforms.
+const (
+	utmpRecordSize  = 384
+	utmpUserProcess = 7
+
+	utmpTypeOffset = 0
+	utmpPIDOffset  = 4
+	utmpLineOffset = 8
+	utmpLineSize   = 32
+	utmpUserOffset = 44
+	utmpUserSize   = 32
+	utmpTimeOffset = 340
+)
+
+type utmpSession struct {
+	user  string
+	line  string
+	pid   int32
+	login int32
+}
+
+// sessionState remembers the sessions of the previous cycle so that new
+// logins can be counted.
+var sessionState = struct {
+	sync.Mutex
+	seen   map[utmpSession]bool
+	logins map[string]uint64
+}{logins: make(map[string]uint64)}
+
+func GetSessionStats(utmpPath string) (*models.SessionStats, error) {
+	data, err := os.ReadFile(utmpPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%utmpRecordSize != 0 {
+		return nil, fmt.Errorf("%s: size %d is not a multiple of the utmp record size", utmpPath, len(data))
+	}
+
+	sessions := parseUtmp(data)
+
+	sessionState.Lock()
+	defer sessionState.Unlock()
+
+	current := make(map[utmpSession]bool, len(sessions))
+	for _, s := range sessions {
+		current[s] = true
+		// The first cycle only records the sessions that already exist.
+		if sessionState.seen != nil && !sessionState.seen[s] {
+			sessionState.logins[s.user]++
+		}
+	}
+	sessionState.seen = current
+
+	counts := make(map[models.UserSessionCount]int)
+	for _, s := range sessions {
+		counts[models.UserSessionCount{User: s.user, Terminal: terminalType(s.line)}]++
+	}
+
+	stats := &models.SessionStats{
+		Logins:    make(map[string]uint64, len(sessionState.logins)),
+		Timestamp: time.Now(),
+	}
+	for key, n := range counts {
+		key.Sessions = n
+		stats.Active = append(stats.Active, key)
+	}
+	sort.Slice(stats.Active, func(i, j int) bool {
+		if stats.Active[i].User != stats.Active[j].User {
+			return stats.Active[i].User < stats.Active[j].User
+		}
+		return stats.Active[i].Terminal < stats.Active[j].Terminal
+	})
+	for user, n := range sessionState.logins {
+		stats.Logins[user] = n
+	}
+
+	return stats, nil
+}
+
+// parseUtmp returns the USER_PROCESS records, i.e. the logged-in sessions.
+func parseUtmp(data []byte) []utmpSession {
+	var sessions []utmpSession
+	for off := 0; off+utmpRecordSize <= len(data); off += utmpRecordSize {
+		record := data[off : off+utmpRecordSize]
+		if int16(binary.LittleEndian.Uint16(record[utmpTypeOffset:])) != utmpUserProcess {
+			continue
+		}
+		sessions = append(sessions, utmpSession{
+			user:  cString(record[utmpUserOffset : utmpUserOffset+utmpUserSize]),
+			line:  cString(record[utmpLineOffset : utmpLineOffset+utmpLineSize]),
+			pid:   int32(binary.LittleEndian.Uint32(record[utmpPIDOffset:])),
+			login: int32(binary.LittleEndian.Uint32(record[utmpTimeOffset:])),
+		})
+	}
+	return sessions
+}
+
+func terminalType(line string) string {
+	switch {
+	case strings.HasPrefix(line, "pts/"):
+		return "pts"
+	case strings.HasPrefix(line, "tty"), line == "console":
+		return "tty"
+	default:
+		return "other"
+	}
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
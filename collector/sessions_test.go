@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"system-monitoring/models"
+)
+
+const utmpDeadProcess = 8
+
+// utmpRecord encodes one glibc struct utmp record.
+func utmpRecord(typ int16, pid int32, line, user string, login int32) []byte {
+	record := make([]byte, utmpRecordSize)
+	binary.LittleEndian.PutUint16(record[utmpTypeOffset:], uint16(typ))
+	binary.LittleEndian.PutUint32(record[utmpPIDOffset:], uint32(pid))
+	copy(record[utmpLineOffset:utmpLineOffset+utmpLineSize], line)
+	copy(record[utmpUserOffset:utmpUserOffset+utmpUserSize], user)
+	binary.LittleEndian.PutUint32(record[utmpTimeOffset:], uint32(login))
+	return record
+}
+
+// resetSessionState forgets the sessions seen so far, now and after the test.
+func resetSessionState(t *testing.T) {
+	reset := func() {
+		sessionState.Lock()
+		sessionState.seen = nil
+		sessionState.logins = make(map[string]uint64)
+		sessionState.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestGetSessionStatsFixture(t *testing.T) {
+	resetSessionState(t)
+	path := filepath.Join(t.TempDir(), "utmp")
+	write := func(records ...[]byte) {
+		t.Helper()
+		if err := os.WriteFile(path, slices.Concat(records...), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const bootTime = 2 // BOOT_TIME
+	alice := utmpRecord(utmpUserProcess, 100, "pts/0", "alice", 1700000000)
+	// A 32-byte user name fills the field without a terminating NUL.
+	long := strings.Repeat("u", utmpUserSize)
+	write(
+		utmpRecord(bootTime, 0, "~", "reboot", 1699990000),
+		alice,
+		utmpRecord(utmpUserProcess, 101, "pts/1", "alice", 1700000100),
+		utmpRecord(utmpUserProcess, 102, "tty1", long, 1700000200),
+		utmpRecord(utmpDeadProcess, 103, "pts/2", "bob", 1700000300),
+	)
+
+	stats, err := GetSessionStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.UserSessionCount{
+		{User: "alice", Terminal: "pts", Sessions: 2},
+		{User: long, Terminal: "tty", Sessions: 1},
+	}
+	if !slices.Equal(stats.Active, want) {
+		t.Errorf("active = %+v, want %+v", stats.Active, want)
+	}
+	if len(stats.Logins) != 0 {
+		t.Errorf("sessions present at startup counted as logins: %v", stats.Logins)
+	}
+
+	// bob logs in on a reused pts/2 slot, and one of alice's sessions ends.
+	write(
+		alice,
+		utmpRecord(utmpDeadProcess, 101, "pts/1", "alice", 1700000100),
+		utmpRecord(utmpUserProcess, 104, "pts/2", "bob", 1700000400),
+	)
+	stats, err = GetSessionStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []models.UserSessionCount{
+		{User: "alice", Terminal: "pts", Sessions: 1},
+		{User: "bob", Terminal: "pts", Sessions: 1},
+	}
+	if !slices.Equal(stats.Active, want) {
+		t.Errorf("active = %+v, want %+v", stats.Active, want)
+	}
+	if len(stats.Logins) != 1 || stats.Logins["bob"] != 1 {
+		t.Errorf("logins = %v, want bob once", stats.Logins)
+	}
+
+	// A short trailing record means the file is not utmp, or was read while
+	// being written.
+	write(alice, utmpRecord(utmpUserProcess, 105, "pts/3", "carol", 1700000500)[:100])
+	if _, err := GetSessionStats(path); err == nil || !strings.Contains(err.Error(), "not a multiple") {
+		t.Errorf("short record error = %v", err)
+	}
+	if got := parseUtmp(slices.Concat(alice, make([]byte, 100))); len(got) != 1 || got[0].user != "alice" || got[0].pid != 100 || got[0].login != 1700000000 {
+		t.Errorf("parseUtmp with a short tail = %+v", got)
+	}
+}
@@ -0,0 +1,232 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// Sink delivers collected metrics to a storage backend. Metric keys are
+// series in Prometheus notation, e.g. cpu_usage_percent or
+// log_matches_total{file="app.log",pattern="error"}, and timestamp is the
+// time the cycle was collected.
+type Sink interface {
+	Name() string
+	Ping(ctx context.Context) error
+	SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error
+	Close() error
+}
+
+// NewSink creates the sink for one DATABASE_TYPE entry.
+func NewSink(sinkType string, config *models.Config, logger *slog.Logger) (Sink, error) {
+	switch sinkType {
+	case "victoriametrics":
+		return NewVictoriaClient(config.GetVictoriaMetricsURL(), config.Database, logger), nil
+	case "remote_write":
+		return NewRemoteWriteClient(config.RemoteWrite, config.Database, logger), nil
+	case "influxdb":
+		return NewInfluxClient(config.InfluxDB, config.Database, logger)
+	case "otlp":
+		inventory, err := GetInventoryStats(config.Monitoring.ProcPath, config.Monitoring.SysPath)
+		if err != nil {
+			logger.Warn("Host inventory unavailable for OTLP resource attributes", "error", err)
+		}
+		return NewOTLPExporter(config.OTLP, config.Database, inventory, logger)
+	case "graphite":
+		return NewGraphiteClient(config.Graphite, logger)
+	case "statsd":
+		return NewStatsDClient(config.StatsD, logger), nil
+	case "mqtt":
+		return NewMQTTClient(config.MQTT, logger)
+	case "file":
+		return NewFileSink(config.File, logger)
+	case "prometheus":
+		return NewPrometheusExporter(config.Prometheus.ListenAddress, config.Prometheus.Path, logger)
+	default:
+		return nil, fmt.Errorf("unknown database type %q", sinkType)
+	}
+}
+
+// NewSinks creates every sink listed in DATABASE_TYPE behind a MultiSink.
+func NewSinks(config *models.Config, logger *slog.Logger) (*MultiSink, error) {
+	var sinks []Sink
+	for _, sinkType := range config.Database.SinkTypes() {
+		sink, err := NewSink(sinkType, config, logger)
+		if err == nil && config.Buffer.Enabled && bufferable(sinkType) {
+			var buffered *BufferedSink
+			if buffered, err = NewBufferedSink(sink, config.Buffer, logger); err != nil {
+				sink.Close()
+			}
+			sink = buffered
+		}
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) == 0 {
+		return nil, errors.New("no database type configured")
+	}
+	return NewMultiSink(sinks, config.Database.SinkTimeout, logger), nil
+}
+
+// bufferable reports whether failed batches of the sink type can be replayed
+// later. The Prometheus endpoint only serves the latest cycle and StatsD
+// stamps samples on arrival, so neither can take old data.
+func bufferable(sinkType string) bool {
+	return sinkType != "prometheus" && sinkType != "statsd"
+}
+
+// MultiSink fans every call out to several sinks concurrently and waits at
+// most timeout for them. A sink that has not returned by then is reported as
+// failed and left to finish in the background; until it does, later calls
+// skip it. A slow backend, or one that ignores its context, therefore delays
+// neither the others nor the collection cycle beyond the timeout.
+type MultiSink struct {
+	sinks   []Sink
+	busy    []atomic.Bool // set while a call to the sink is running
+	timeout time.Duration
+	logger  *slog.Logger
+}
+
+func NewMultiSink(sinks []Sink, timeout time.Duration, logger *slog.Logger) *MultiSink {
+	return &MultiSink{
+		sinks:   sinks,
+		busy:    make([]atomic.Bool, len(sinks)),
+		timeout: timeout,
+		logger:  logger,
+	}
+}
+
+func (m *MultiSink) Name() string {
+	names := make([]string, len(m.sinks))
+	for i, s := range m.sinks {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Ping succeeds when at least one sink is reachable.
+func (m *MultiSink) Ping(ctx context.Context) error {
+	errs := m.each(ctx, func(ctx context.Context, s Sink) error {
+		return s.Ping(ctx)
+	})
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			m.logger.Warn("Sink is not accessible", "sink", m.sinks[i].Name(), "error", err)
+		}
+	}
+	if failed == len(m.sinks) {
+		return fmt.Errorf("no sink is accessible: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// SendMetrics sends to every sink and returns the joined errors of those that
+// failed.
+func (m *MultiSink) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	errs := m.each(ctx, func(ctx context.Context, s Sink) error {
+		return s.SendMetrics(ctx, metrics, timestamp)
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			m.logger.Error("Failed to send metrics", "sink", m.sinks[i].Name(), "error", err)
+			errs[i] = fmt.Errorf("%s: %w", m.sinks[i].Name(), err)
+		} else {
+			m.logger.Debug("Sent metrics", "sink", m.sinks[i].Name(), "count", len(metrics))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *MultiSink) Close() error {
+	var errs []error
+	for _, s := range m.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ClockOffset reports the offset of the first sink able to measure one.
+func (m *MultiSink) ClockOffset() (time.Duration, bool) {
+	for _, s := range m.sinks {
+		if c, ok := s.(clockOffsetter); ok {
+			if offset, ok := c.ClockOffset(); ok {
+				return offset, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// BufferStats collects the queue statistics of the buffered sinks.
+func (m *MultiSink) BufferStats() []models.SinkBufferStats {
+	var stats []models.SinkBufferStats
+	for _, s := range m.sinks {
+		if b, ok := s.(bufferStatter); ok {
+			stats = append(stats, b.BufferStats()...)
+		}
+	}
+	return stats
+}
+
+func (m *MultiSink) each(ctx context.Context, fn func(context.Context, Sink) error) []error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	type result struct {
+		i   int
+		err error
+	}
+	results := make(chan result, len(m.sinks))
+	errs := make([]error, len(m.sinks))
+	pending := make(map[int]bool, len(m.sinks))
+
+	for i, s := range m.sinks {
+		if !m.busy[i].CompareAndSwap(false, true) {
+			errs[i] = errors.New("previous call has not returned yet, skipped")
+			continue
+		}
+		pending[i] = true
+		go func(i int, s Sink) {
+			err := fn(ctx, s)
+			m.busy[i].Store(false)
+			results <- result{i, err}
+		}(i, s)
+	}
+
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			errs[r.i] = r.err
+			delete(pending, r.i)
+		case <-ctx.Done():
+			for i := range pending {
+				errs[i] = fmt.Errorf("no response within %s: %w", m.timeout, ctx.Err())
+			}
+			return errs
+		}
+	}
+	return errs
+}
+
+// clockOffsetter is implemented by sinks that can tell how far the backend's
+// clock is from ours.
+type clockOffsetter interface {
+	ClockOffset() (time.Duration, bool)
+}
@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type namedSink struct {
+	Sink
+	name string
+}
+
+func (s namedSink) Name() string { return s.name }
+
+// stuckSink blocks every call until release is closed, ignoring its context.
+type stuckSink struct {
+	release chan struct{}
+	calls   chan struct{}
+}
+
+func (s *stuckSink) Name() string { return "stuck" }
+func (s *stuckSink) Close() error { return nil }
+
+func (s *stuckSink) Ping(ctx context.Context) error {
+	return s.SendMetrics(ctx, nil, time.Time{})
+}
+
+func (s *stuckSink) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	s.calls <- struct{}{}
+	<-s.release
+	return nil
+}
+
+func TestMultiSinkFanOut(t *testing.T) {
+	a, b := &recordingSink{}, &recordingSink{}
+	m := NewMultiSink([]Sink{namedSink{a, "a"}, namedSink{b, "b"}}, time.Second, discardLogger())
+	if m.Name() != "a,b" {
+		t.Errorf("name = %q", m.Name())
+	}
+
+	ts := time.UnixMilli(1000)
+	if err := m.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 1}, ts); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*recordingSink{a, b} {
+		if len(s.sent) != 1 || !s.sent[0].timestamp.Equal(ts) || s.sent[0].metrics["cpu_usage_percent"] != 1 {
+			t.Errorf("sink received %+v", s.sent)
+		}
+	}
+}
+
+func TestMultiSinkPartialFailure(t *testing.T) {
+	ok, failing := &recordingSink{}, &recordingSink{fail: true}
+	m := NewMultiSink([]Sink{namedSink{ok, "ok"}, namedSink{failing, "down"}}, time.Second, discardLogger())
+
+	err := m.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 1}, time.Now())
+	if err == nil || !strings.HasPrefix(err.Error(), "down: sink unavailable") {
+		t.Errorf("error = %v, want only the failing sink", err)
+	}
+	if len(ok.sent) != 1 {
+		t.Errorf("healthy sink received %d batches", len(ok.sent))
+	}
+	// Ping succeeds while any sink is reachable.
+	if err := m.Ping(t.Context()); err != nil {
+		t.Errorf("Ping = %v", err)
+	}
+}
+
+func TestMultiSinkSlowSink(t *testing.T) {
+	stuck := &stuckSink{release: make(chan struct{}), calls: make(chan struct{}, 10)}
+	fast := &recordingSink{}
+	m := NewMultiSink([]Sink{stuck, namedSink{fast, "fast"}}, 50*time.Millisecond, discardLogger())
+
+	start := time.Now()
+	err := m.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 1}, time.Now())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SendMetrics took %v with a 50ms timeout", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stuck: no response") {
+		t.Errorf("error = %v", err)
+	}
+	if len(fast.sent) != 1 {
+		t.Errorf("fast sink received %d batches", len(fast.sent))
+	}
+
+	// While the first call is still running the sink is skipped, not called
+	// again.
+	err = m.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 2}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "stuck: previous call has not returned yet") {
+		t.Errorf("error while busy = %v", err)
+	}
+	if len(stuck.calls) != 1 || len(fast.sent) != 2 {
+		t.Errorf("stuck sink called %d times, fast sink received %d batches", len(stuck.calls), len(fast.sent))
+	}
+
+	close(stuck.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for m.busy[0].Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := m.SendMetrics(t.Context(), map[string]float64{"cpu_usage_percent": 3}, time.Now()); err != nil {
+		t.Errorf("after the sink recovered: %v", err)
+	}
+}
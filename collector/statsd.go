@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"system-monitoring/models"
+)
+
+// StatsDClient sends metrics to a StatsD daemon over UDP. Gauges are sent as
+// gauges; counters and histogram parts, which are cumulative here, are sent
+// as the increase since the previous cycle, so the first cycle only records
+// their values. StatsD assigns its own timestamps.
+type StatsDClient struct {
+	config   models.StatsDConfig
+	template pathTemplate
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	previous map[string]float64
+}
+
+func NewStatsDClient(config models.StatsDConfig, logger *slog.Logger) *StatsDClient {
+	return &StatsDClient{
+		config:   config,
+		template: newPathTemplate(config.Prefix, config.Template),
+		logger:   logger,
+		previous: make(map[string]float64),
+	}
+}
+
+func (s *StatsDClient) Name() string {
+	return "statsd"
+}
+
+// Ping resolves the daemon's address; UDP offers no way to tell whether
+// anything is listening.
+func (s *StatsDClient) Ping(ctx context.Context) error {
+	if _, err := net.DefaultResolver.LookupHost(ctx, hostOf(s.config.Address)); err != nil {
+		return fmt.Errorf("resolving StatsD address: %w", err)
+	}
+	return nil
+}
+
+func (s *StatsDClient) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
+	if len(metrics) == 0 {
+		s.logger.DebugContext(ctx, "No metrics to send")
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := make(map[string]float64)
+	// keys holds the counter behind each line, empty for gauges.
+	var lines, keys []string
+	for _, series := range parseBatch(metrics) {
+		if math.IsNaN(series.Value) || math.IsInf(series.Value, 0) {
+			continue
+		}
+
+		path, remaining := s.template.render(series.Name, series.Labels)
+		suffix := ""
+		if s.config.Tags {
+			suffix = dogStatsDTags(remaining)
+		} else {
+			path = appendPathComponents(path, remaining)
+		}
+
+		if series.Family.Type == "gauge" {
+			if series.Value < 0 {
+				// A signed gauge value is read as a relative change, so
+				// reset to zero first.
+				lines = append(lines, path+":0|g"+suffix+"\n")
+				keys = append(keys, "")
+			}
+			lines = append(lines, path+":"+formatSampleValue(series.Value)+"|g"+suffix+"\n")
+			keys = append(keys, "")
+			continue
+		}
+
+		current[series.Key] = series.Value
+		previous, ok := s.previous[series.Key]
+		if !ok {
+			continue
+		}
+		delta := series.Value - previous
+		if delta < 0 {
+			// Counter reset
+			delta = series.Value
+		}
+		lines = append(lines, path+":"+formatSampleValue(delta)+"|c"+suffix+"\n")
+		keys = append(keys, series.Key)
+	}
+
+	s.logger.DebugContext(ctx, "Sending metrics to StatsD",
+		"count", len(lines),
+		"address", s.config.Address)
+
+	if written, err := sendLines(ctx, "udp", s.config.Address, lines); err != nil {
+		// Counters in the datagrams that went out move on; the others keep
+		// their old value so that the next cycle sends the increase of
+		// both. Counters seen for the first time had nothing to send.
+		for _, key := range keys[:written] {
+			if key != "" {
+				s.previous[key] = current[key]
+			}
+		}
+		for key, value := range current {
+			if _, ok := s.previous[key]; !ok {
+				s.previous[key] = value
+			}
+		}
+		return err
+	}
+	s.previous = current
+	return nil
+}
+
+func (s *StatsDClient) Close() error {
+	return nil
+}
+
+func dogStatsDTags(labels []seriesLabel) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	tags := make([]string, len(labels))
+	for i, l := range labels {
+		tags[i] = dogStatsDTagEscaper.Replace(l.Name) + ":" + dogStatsDTagEscaper.Replace(l.Value)
+	}
+	return "|#" + strings.Join(tags, ",")
+}
+
+var dogStatsDTagEscaper = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+func hostOf(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
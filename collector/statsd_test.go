@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"system-monitoring/models"
+)
+
+func TestStatsDClientCounters(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := NewStatsDClient(models.StatsDConfig{
+		Address:  conn.LocalAddr().String(),
+		Prefix:   "sysmon",
+		Template: "{name}",
+	}, discardLogger())
+
+	receive := func() string {
+		t.Helper()
+		buf := make([]byte, maxDatagramSize)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+	send := func(requests, temperature float64) error {
+		return client.SendMetrics(context.Background(), map[string]float64{
+			"requests_total":    requests,
+			"temperature_delta": temperature,
+		}, time.Now())
+	}
+
+	// The first cycle only records the counter.
+	if err := send(10, 1.5); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := receive(), "sysmon.temperature_delta:1.5|g\n"; got != want {
+		t.Errorf("first cycle sent %q, want %q", got, want)
+	}
+
+	// A failed cycle must not lose its increase.
+	address := client.config.Address
+	client.config.Address = "invalid address"
+	if err := send(15, 1); err == nil {
+		t.Fatal("expected an error")
+	}
+	client.config.Address = address
+
+	if err := send(20, -2); err != nil {
+		t.Fatal(err)
+	}
+	got := receive()
+	for _, want := range []string{"sysmon.requests_total:10|c\n", "sysmon.temperature_delta:0|g\nsysmon.temperature_delta:-2|g\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("third cycle sent %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestStatsDClientPartialFailure(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := NewStatsDClient(models.StatsDConfig{
+		Address:  conn.LocalAddr().String(),
+		Template: "{name}",
+	}, discardLogger())
+
+	// The second counter gets a datagram of its own that is too large for
+	// UDP, so only the first one goes out.
+	large := formatMetric("z_total", map[string]string{"path": strings.Repeat("x", 70000)})
+	send := func(small, big float64) error {
+		return client.SendMetrics(context.Background(), map[string]float64{
+			"a_total": small,
+			large:     big,
+		}, time.Now())
+	}
+
+	if err := send(10, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(15, 200); err == nil {
+		t.Fatal("expected an error for the oversized datagram")
+	}
+
+	buf := make([]byte, maxDatagramSize)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(buf[:n]), "a_total:5|c\n"; got != want {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+
+	// The increase that went out is not sent again; the lost one is.
+	if got := client.previous["a_total"]; got != 15 {
+		t.Errorf("a_total baseline = %v, want 15", got)
+	}
+	if got := client.previous[large]; got != 100 {
+		t.Errorf("z_total baseline = %v, want 100", got)
+	}
+}
@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"system-monitoring/models"
+)
+
+var (
+	mdDiskCountRe = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	mdSyncRe      = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*(?:([\d.]+)%|DELAYED|PENDING)`)
+)
+
+func GetStorageHealthStats(procPath, sysPath string) (*models.StorageHealthStats, error) {
+	stats := &models.StorageHealthStats{Timestamp: time.Now()}
+
+	data, err := os.ReadFile(filepath.Join(procPath, "mdstat"))
+	if err == nil {
+		stats.Arrays = parseMDStat(string(data))
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	devices, err := readBlockDevices(filepath.Join(sysPath, "block"))
+	if err != nil {
+		return nil, err
+	}
+	stats.Devices = devices
+
+	return stats, nil
+}
+
+// parseMDStat parses /proc/mdstat. Each array starts with a line such as
+//
+//	md1 : active raid5 sdc1[2](F) sdd1[3](S) sdb1[1] sda1[0]
+//
+// followed by indented lines holding the [required/active] disk counts and,
+// while one is running, the resync or recovery progress.
+func parseMDStat(data string) []models.MDArrayStats {
+	var arrays []models.MDArrayStats
+	var current *models.MDArrayStats
+
+	for _, line := range strings.Split(data, "\n") {
+		if strings.HasPrefix(line, "md") && strings.Contains(line, " : ") {
+			name, rest, _ := strings.Cut(line, " : ")
+			fields := strings.Fields(rest)
+			if len(fields) == 0 {
+				continue
+			}
+
+			arrays = append(arrays, models.MDArrayStats{Name: strings.TrimSpace(name), State: fields[0]})
+			current = &arrays[len(arrays)-1]
+			fields = fields[1:]
+
+			if len(fields) > 0 && strings.HasPrefix(fields[0], "(") {
+				// e.g. "active (auto-read-only)"
+				current.State = strings.Trim(fields[0], "()")
+				fields = fields[1:]
+			}
+			if len(fields) > 0 && !strings.Contains(fields[0], "[") {
+				current.Level = fields[0]
+				fields = fields[1:]
+			}
+			for _, disk := range fields {
+				switch {
+				case strings.HasSuffix(disk, "(F)"):
+					current.FailedDisks++
+				case strings.HasSuffix(disk, "(S)"):
+					current.SpareDisks++
+				}
+			}
+			continue
+		}
+
+		if current == nil || !strings.HasPrefix(line, " ") {
+			current = nil
+			continue
+		}
+
+		if m := mdDiskCountRe.FindStringSubmatch(line); m != nil {
+			current.RequiredDisks, _ = strconv.Atoi(m[1])
+			current.ActiveDisks, _ = strconv.Atoi(m[2])
+		}
+		if m := mdSyncRe.FindStringSubmatch(line); m != nil {
+			current.SyncAction = m[1]
+			if m[2] != "" {
+				current.SyncProgressPct, _ = strconv.ParseFloat(m[2], 64)
+			}
+		}
+	}
+
+	return arrays
+}
+
+// readBlockDevices lists whole disks from /sys/block, skipping loop and ram
+// devices which carry no health information.
+func readBlockDevices(blockPath string) ([]models.BlockDeviceStats, error) {
+	entries, err := os.ReadDir(blockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []models.BlockDeviceStats
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			continue
+		}
+
+		device := models.BlockDeviceStats{Name: name}
+		if rotational, err := readSysfsInt(filepath.Join(blockPath, name, "queue", "rotational")); err == nil {
+			device.Rotational = rotational == 1
+		}
+		if sectors, err := readSysfsInt(filepath.Join(blockPath, name, "size")); err == nil {
+			// The size attribute is always in 512-byte sectors.
+			device.SizeBytes = sectors * 512
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+func readSysfsInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"slices"
+	"testing"
+
+	"system-monitoring/models"
+)
+
+const mdstatFixture = `Personalities : [raid1] [raid6] [raid5] [raid4] [raid0]
+md2 : active raid5 sdc1[2](F) sdd1[3](S) sdb1[1] sda1[0]
+      1953257472 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+      [=>...................]  recovery =  8.5% (83091456/976628736) finish=83.5min speed=178322K/sec
+      bitmap: 0/8 pages [0KB], 65536KB chunk
+
+md1 : active (auto-read-only) raid1 sdf1[1] sde1[0]
+      488254464 blocks super 1.2 [2/2] [UU]
+        resync=PENDING
+
+md0 : active raid0 sdh1[1] sdg1[0]
+      976508928 blocks super 1.2 512k chunks
+
+md127 : inactive sdi[0](S)
+      976630488 blocks super 1.2
+
+unused devices: <none>
+`
+
+func TestGetStorageHealthStatsFixture(t *testing.T) {
+	proc, sys := t.TempDir(), t.TempDir()
+	writeTree(t, proc, map[string]string{"mdstat": mdstatFixture})
+	writeTree(t, sys, map[string]string{
+		"block/sda/queue/rotational":     "1\n",
+		"block/sda/size":                 "1953525168\n",
+		"block/nvme0n1/queue/rotational": "0\n",
+		"block/nvme0n1/size":             "1000215216\n",
+		"block/loop0/size":               "0\n",
+		"block/ram0/size":                "8192\n",
+	})
+
+	stats, err := GetStorageHealthStats(proc, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantArrays := []models.MDArrayStats{
+		{Name: "md2", State: "active", Level: "raid5", RequiredDisks: 3, ActiveDisks: 2, FailedDisks: 1, SpareDisks: 1, SyncAction: "recovery", SyncProgressPct: 8.5},
+		{Name: "md1", State: "auto-read-only", Level: "raid1", RequiredDisks: 2, ActiveDisks: 2, SyncAction: "resync"},
+		{Name: "md0", State: "active", Level: "raid0"},
+		{Name: "md127", State: "inactive", SpareDisks: 1},
+	}
+	if !slices.Equal(stats.Arrays, wantArrays) {
+		t.Errorf("arrays:\n%+v\nwant\n%+v", stats.Arrays, wantArrays)
+	}
+
+	wantDevices := []models.BlockDeviceStats{
+		{Name: "nvme0n1", SizeBytes: 1000215216 * 512},
+		{Name: "sda", Rotational: true, SizeBytes: 1953525168 * 512},
+	}
+	if !slices.Equal(stats.Devices, wantDevices) {
+		t.Errorf("devices = %+v, want %+v", stats.Devices, wantDevices)
+	}
+}
+
+func TestGetStorageHealthStatsWithoutMD(t *testing.T) {
+	proc, sys := t.TempDir(), t.TempDir()
+	writeTree(t, sys, map[string]string{"block/sda/size": "2048\n"})
+
+	stats, err := GetStorageHealthStats(proc, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Arrays) != 0 || len(stats.Devices) != 1 || stats.Devices[0].SizeBytes != 2048*512 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
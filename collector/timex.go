@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"syscall"
+	"time"
+
+	"system-monitoring/models"
+)
+
+const (
+	timexStatusUnsync = 0x0040 // STA_UNSYNC
+	timexStatusNano   = 0x2000 // STA_NANO
+	timexStateError   = 5      // TIME_ERROR
+)
+
+// GetTimexStats reads the kernel clock discipline state with a read-only
+// adjtimex call.
+func GetTimexStats() (*models.TimexStats, error) {
+	var tx syscall.Timex
+	state, err := syscall.Adjtimex(&tx)
+	if err != nil {
+		return nil, err
+	}
+	return timexStats(&tx, state), nil
+}
+
+// timexStats converts the result of adjtimex into clock statistics.
+func timexStats(tx *syscall.Timex, state int) *models.TimexStats {
+	offsetUnit := time.Microsecond
+	if tx.Status&timexStatusNano != 0 {
+		offsetUnit = time.Nanosecond
+	}
+
+	return &models.TimexStats{
+		Offset: time.Duration(int64(tx.Offset)) * offsetUnit,
+		// The frequency is in parts per million with a 16-bit fractional part.
+		FrequencyPPM: float64(int64(tx.Freq)) / 65536,
+		MaxError:     time.Duration(int64(tx.Maxerror)) * time.Microsecond,
+		EstError:     time.Duration(int64(tx.Esterror)) * time.Microsecond,
+		Synced:       state != timexStateError && tx.Status&timexStatusUnsync == 0,
+		Timestamp:    time.Now(),
+	}
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTimexStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		tx     syscall.Timex
+		state  int
+		offset time.Duration
+		synced bool
+	}{
+		{
+			name:   "microsecond offset",
+			tx:     syscall.Timex{Offset: -250, Freq: 12*65536 + 32768, Maxerror: 16000, Esterror: 1000},
+			offset: -250 * time.Microsecond,
+			synced: true,
+		},
+		{
+			name:   "nanosecond offset",
+			tx:     syscall.Timex{Offset: 1500, Freq: 12*65536 + 32768, Maxerror: 16000, Esterror: 1000, Status: timexStatusNano},
+			offset: 1500 * time.Nanosecond,
+			synced: true,
+		},
+		{
+			name:   "unsynchronised",
+			tx:     syscall.Timex{Freq: 12*65536 + 32768, Maxerror: 16000, Esterror: 1000, Status: timexStatusUnsync},
+			synced: false,
+		},
+		{
+			name:   "clock error state",
+			tx:     syscall.Timex{Freq: 12*65536 + 32768, Maxerror: 16000, Esterror: 1000},
+			state:  timexStateError,
+			synced: false,
+		},
+	}
+
+	for _, tt := range tests {
+		stats := timexStats(&tt.tx, tt.state)
+		if stats.Offset != tt.offset || stats.Synced != tt.synced {
+			t.Errorf("%s: offset %v synced %v, want %v %v", tt.name, stats.Offset, stats.Synced, tt.offset, tt.synced)
+		}
+		if stats.FrequencyPPM != 12.5 || stats.MaxError != 16*time.Millisecond || stats.EstError != time.Millisecond {
+			t.Errorf("%s: frequency %v ppm, errors %v %v", tt.name, stats.FrequencyPPM, stats.MaxError, stats.EstError)
+		}
+	}
+}
+
+// offsetSink is a sink that reports a fixed backend clock offset.
+type offsetSink struct {
+	recordingSink
+	offset time.Duration
+	ok     bool
+}
+
+func (s *offsetSink) ClockOffset() (time.Duration, bool) { return s.offset, s.ok }
+
+func TestAddBackendClockDrift(t *testing.T) {
+	tests := []struct {
+		offset   time.Duration
+		ok       bool
+		exceeded float64
+	}{
+		{offset: 500 * time.Millisecond, ok: true, exceeded: 0},
+		{offset: -3 * time.Second, ok: true, exceeded: 1},
+		{ok: false},
+	}
+	for _, tt := range tests {
+		metrics := make(map[string]float64)
+		sink := &offsetSink{offset: tt.offset, ok: tt.ok}
+		addBackendClockDrift(metrics, NewMultiSink([]Sink{sink}, time.Second, discardLogger()), 2*time.Second, discardLogger())
+
+		if !tt.ok {
+			if len(metrics) != 0 {
+				t.Errorf("metrics without an offset: %v", metrics)
+			}
+			continue
+		}
+		if metrics["clock_backend_offset_seconds"] != tt.offset.Seconds() || metrics["clock_backend_drift_exceeded"] != tt.exceeded {
+			t.Errorf("offset %v: %v", tt.offset, metrics)
+		}
+	}
+
+	// Sinks that cannot measure an offset add nothing.
+	metrics := make(map[string]float64)
+	addBackendClockDrift(metrics, &recordingSink{}, time.Second, discardLogger())
+	if len(metrics) != 0 {
+		t.Errorf("metrics = %v", metrics)
+	}
+}
@@ -7,49 +7,64 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"system-monitoring/models"
 )
 
 type VictoriaClient struct {
-	baseURL    string
-	httpClient *http.Client
-	logger     *slog.Logger
+	baseURL  string
+	delivery *httpDelivery
+	logger   *slog.Logger
+
+	clockOffset    time.Duration
+	hasClockOffset bool
 }
 
-func NewVictoriaClient(baseURL string, logger *slog.Logger) *VictoriaClient {
+func NewVictoriaClient(baseURL string, database models.DatabaseConfig, logger *slog.Logger) *VictoriaClient {
 	return &VictoriaClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		logger: logger,
+		baseURL:  baseURL,
+		delivery: newHTTPDelivery(database, logger),
+		logger:   logger,
 	}
 }
 
-func (v *VictoriaClient) SendMetrics(ctx context.Context, metrics map[string]float64) error {
+func (v *VictoriaClient) Name() string {
+	return "victoriametrics"
+}
+
+func (v *VictoriaClient) SendMetrics(ctx context.Context, metrics map[string]float64, timestamp time.Time) error {
 	if len(metrics) == 0 {
 		v.logger.DebugContext(ctx, "No metrics to send")
 		return nil
 	}
 
-	// Convert metrics to Prometheus format
-	var lines []string
-	for name, value := range metrics {
-		line := fmt.Sprintf("%s %f", name, value)
-		lines = append(lines, line)
-	}
+	for _, batch := range v.delivery.batches(metrics) {
+		// Convert metrics to Prometheus format
+		var lines []string
+		for _, name := range batch {
+			line := fmt.Sprintf("%s %f %d", name, metrics[name], timestamp.UnixMilli())
+			lines = append(lines, line)
+		}
 
-	data := strings.Join(lines, "\n")
+		data := strings.Join(lines, "\n")
 
-	v.logger.DebugContext(ctx, "Sending metrics to VictoriaMetrics",
-		"count", len(metrics),
-		"data", data,
-		"url", v.baseURL)
+		v.logger.DebugContext(ctx, "Sending metrics to VictoriaMetrics",
+			"count", len(batch),
+			"data", data,
+			"url", v.baseURL)
 
-	return v.sendData(ctx, data)
+		if err := v.sendData(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Ping checks if VictoriaMetrics is accessible
 func (v *VictoriaClient) Ping(ctx context.Context) error {
+	// A stale offset must not be reported while the backend is unreachable.
+	v.hasClockOffset = false
+
 	url := v.baseURL + "/health"
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -57,40 +72,48 @@ func (v *VictoriaClient) Ping(ctx context.Context) error {
 		return fmt.Errorf("creating ping request: %w", err)
 	}
 
-	resp, err := v.httpClient.Do(req)
+	sent := time.Now()
+	resp, err := v.delivery.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("ping request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	received := time.Now()
 
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("VictoriaMetrics health check failed: %s", resp.Status)
 	}
 
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		// The header has one second resolution, so compare its midpoint with
+		// the midpoint of the round trip.
+		serverTime := date.Add(500 * time.Millisecond)
+		localTime := sent.Add(received.Sub(sent) / 2)
+		v.clockOffset = serverTime.Sub(localTime)
+		v.hasClockOffset = true
+	}
+
 	v.logger.InfoContext(ctx, "VictoriaMetrics is healthy")
 	return nil
 }
 
-func (v *VictoriaClient) sendData(ctx context.Context, data string) error {
-	url := v.baseURL + "/api/v1/import/prometheus"
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(data))
-	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
-	}
+// ClockOffset returns how far the VictoriaMetrics clock was ahead of the local
+// clock at the last successful Ping, derived from the response's Date header.
+// It is unset while the most recent Ping failed.
+func (v *VictoriaClient) ClockOffset() (time.Duration, bool) {
+	return v.clockOffset, v.hasClockOffset
+}
 
-	req.Header.Set("Content-Type", "text/plain")
+func (v *VictoriaClient) Close() error {
+	v.delivery.client.CloseIdleConnections()
+	return nil
+}
 
-	resp, err := v.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("sending request: %w", err)
-	}
-	defer resp.Body.Close()
+func (v *VictoriaClient) sendData(ctx context.Context, data string) error {
+	url := v.baseURL + "/api/v1/import/prometheus"
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("VictoriaMetrics returned status %d: %s", resp.StatusCode, resp.Status)
-	}
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain")
 
-	v.logger.DebugContext(ctx, "Successfully sent metrics", "status", resp.Status)
-	return nil
+	return v.delivery.post(ctx, url, header, []byte(data))
 }
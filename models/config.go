@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v11"
@@ -13,15 +14,51 @@ type Config struct {
 	Environment        string        `env:"ENV" envDefault:"local"`
 	CollectionInterval time.Duration `env:"COLLECTION_INTERVAL" envDefault:"5s"`
 
-	Database   DatabaseConfig   `envPrefix:"DATABASE_"`
-	Monitoring MonitoringConfig `envPrefix:"MONITORING_"`
-	Logging    LoggingConfig    `envPrefix:"LOG_"`
+	Database    DatabaseConfig    `envPrefix:"DATABASE_"`
+	Monitoring  MonitoringConfig  `envPrefix:"MONITORING_"`
+	Logging     LoggingConfig     `envPrefix:"LOG_"`
+	LogWatch    LogWatchConfig    `envPrefix:"LOGWATCH_"`
+	Kmsg        KmsgConfig        `envPrefix:"KMSG_"`
+	Timex       TimexConfig       `envPrefix:"TIMEX_"`
+	Docker      DockerConfig      `envPrefix:"DOCKER_"`
+	Interrupts  InterruptsConfig  `envPrefix:"INTERRUPTS_"`
+	Integrity   IntegrityConfig   `envPrefix:"INTEGRITY_"`
+	DirWatch    DirWatchConfig    `envPrefix:"DIRWATCH_"`
+	Prometheus  PrometheusConfig  `envPrefix:"PROMETHEUS_"`
+	RemoteWrite RemoteWriteConfig `envPrefix:"REMOTE_WRITE_"`
+	InfluxDB    InfluxDBConfig    `envPrefix:"INFLUXDB_"`
+	OTLP        OTLPConfig        `envPrefix:"OTLP_"`
+	Graphite    GraphiteConfig    `envPrefix:"GRAPHITE_"`
+	StatsD      StatsDConfig      `envPrefix:"STATSD_"`
+	MQTT        MQTTConfig        `envPrefix:"MQTT_"`
+	File        FileSinkConfig    `envPrefix:"FILE_"`
+	Buffer      BufferConfig      `envPrefix:"BUFFER_"`
 }
 
+// DatabaseConfig selects the sinks metrics are sent to. Type may list several
+// comma-separated backends, e.g. "victoriametrics,influxdb"; each is given
+// SinkTimeout per cycle independently of the others.
 type DatabaseConfig struct {
-	Type string `env:"TYPE" envDefault:"victoriametrics"`
-	URL  string `env:"URL" envDefault:"localhost"`
-	Port int    `env:"PORT" envDefault:"8428"`
+	Type        string        `env:"TYPE" envDefault:"victoriametrics"`
+	URL         string        `env:"URL" envDefault:"localhost"`
+	Port        int           `env:"PORT" envDefault:"8428"`
+	SinkTimeout time.Duration `env:"SINK_TIMEOUT" envDefault:"10s"`
+
+	// Batching and retries of the HTTP based sinks. BatchSize 0 sends each
+	// cycle in a single request.
+	BatchSize    int           `env:"BATCH_SIZE" envDefault:"0"`
+	MaxRetries   int           `env:"MAX_RETRIES" envDefault:"3"`
+	RetryBackoff time.Duration `env:"RETRY_BACKOFF" envDefault:"500ms"`
+}
+
+func (d *DatabaseConfig) SinkTypes() []string {
+	var types []string
+	for _, t := range strings.Split(d.Type, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
 }
 
 type MonitoringConfig struct {
@@ -29,6 +66,28 @@ type MonitoringConfig struct {
 	EnableMemory      bool `env:"ENABLE_MEMORY_MONITORING" envDefault:"true"`
 	EnableTemperature bool `env:"ENABLE_TEMPERATURE_MONITORING" envDefault:"true"`
 	EnableLoad        bool `env:"ENABLE_LOAD_MONITORING" envDefault:"true"`
+	EnableLogWatch    bool `env:"ENABLE_LOGWATCH_MONITORING" envDefault:"false"`
+	EnableKmsg        bool `env:"ENABLE_KMSG_MONITORING" envDefault:"false"`
+	EnableStorage     bool `env:"ENABLE_STORAGE_MONITORING" envDefault:"false"`
+	EnableInventory   bool `env:"ENABLE_INVENTORY_MONITORING" envDefault:"false"`
+	EnableTimex       bool `env:"ENABLE_TIMEX_MONITORING" envDefault:"false"`
+	EnablePower       bool `env:"ENABLE_POWER_MONITORING" envDefault:"false"`
+	EnableContainers  bool `env:"ENABLE_CONTAINER_MONITORING" envDefault:"false"`
+	EnableInterrupts  bool `env:"ENABLE_INTERRUPTS_MONITORING" envDefault:"false"`
+	EnableSchedstat   bool `env:"ENABLE_SCHEDSTAT_MONITORING" envDefault:"false"`
+	EnableNetworkLink bool `env:"ENABLE_NETWORK_LINK_MONITORING" envDefault:"false"`
+	EnableNUMA        bool `env:"ENABLE_NUMA_MONITORING" envDefault:"false"`
+	EnableEDAC        bool `env:"ENABLE_EDAC_MONITORING" envDefault:"false"`
+	EnableSessions    bool `env:"ENABLE_SESSIONS_MONITORING" envDefault:"false"`
+	EnableListeners   bool `env:"ENABLE_LISTENERS_MONITORING" envDefault:"false"`
+	EnableIntegrity   bool `env:"ENABLE_INTEGRITY_MONITORING" envDefault:"false"`
+	EnableDirWatch    bool `env:"ENABLE_DIRWATCH_MONITORING" envDefault:"false"`
+
+	// Roots of the proc and sys filesystems, overridable for containers
+	// that mount the host's filesystems elsewhere.
+	ProcPath string `env:"PROC_PATH" envDefault:"/proc"`
+	SysPath  string `env:"SYS_PATH" envDefault:"/sys"`
+	UtmpPath string `env:"UTMP_PATH" envDefault:"/var/run/utmp"`
 }
 
 type LoggingConfig struct {
@@ -36,6 +95,169 @@ type LoggingConfig struct {
 	File  string `env:"FILE" envDefault:"stdout"`
 }
 
+// LogWatchConfig configures the log file pattern counter. Patterns are given
+// as name=regex pairs separated by ';', e.g. "error=(?i)error;slow=took (?P<value>[0-9.]+)s".
+// A regex cannot contain a literal ';', write it as \x3b instead. A capture
+// group named "value" is parsed as a number and observed into Buckets. Files
+// listed more than once are tailed once.
+type LogWatchConfig struct {
+	Files    []string          `env:"FILES" envSeparator:","`
+	Patterns map[string]string `env:"PATTERNS" envSeparator:";" envKeyValSeparator:"="`
+	Buckets  []float64         `env:"BUCKETS" envSeparator:"," envDefault:"0.005,0.01,0.025,0.05,0.1,0.25,0.5,1,2.5,5,10"`
+}
+
+// KmsgConfig configures the kernel log collector. Path can point at a plain
+// file of kmsg records instead of the device, which is then read from its
+// start rather than its end. With Forward set, every kernel message is also
+// written to the application logger.
+type KmsgConfig struct {
+	Path    string `env:"PATH" envDefault:"/dev/kmsg"`
+	Forward bool   `env:"FORWARD" envDefault:"false"`
+}
+
+// TimexConfig sets how far the local clock may differ from the Date header
+// returned by VictoriaMetrics before it is reported as drifting.
+type TimexConfig struct {
+	MaxBackendDrift time.Duration `env:"MAX_BACKEND_DRIFT" envDefault:"2s"`
+}
+
+// DockerConfig locates the Docker Engine API socket used by the container
+// collector.
+type DockerConfig struct {
+	Socket  string        `env:"SOCKET" envDefault:"/var/run/docker.sock"`
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"10s"`
+}
+
+// InterruptsConfig controls the cardinality of the interrupt counters:
+// "none" keeps one series per CPU and IRQ, "cpu" sums each IRQ over all CPUs
+// and "irq" sums all IRQs on each CPU.
+type InterruptsConfig struct {
+	Aggregation string `env:"AGGREGATION" envDefault:"cpu"`
+}
+
+// IntegrityConfig lists the files and directories whose metadata and content
+// hash are checked every cycle. Directories are walked recursively up to
+// MaxFiles entries; files larger than MaxHashSize are compared by metadata only.
+type IntegrityConfig struct {
+	Paths       []string `env:"PATHS" envSeparator:","`
+	MaxFiles    int      `env:"MAX_FILES" envDefault:"10000"`
+	MaxHashSize int64    `env:"MAX_HASH_SIZE" envDefault:"67108864"`
+}
+
+// DirWatchConfig lists directories to measure as dir=glob pairs separated by
+// ';', e.g. "/var/backups=*.tar.gz;/var/spool/mail=*". Only files whose name
+// matches the glob are counted. Walking a directory stops after MaxFiles
+// files, below MaxDepth levels or once Timeout has passed.
+type DirWatchConfig struct {
+	Paths    map[string]string `env:"PATHS" envSeparator:";" envKeyValSeparator:"="`
+	MaxFiles int               `env:"MAX_FILES" envDefault:"100000"`
+	MaxDepth int               `env:"MAX_DEPTH" envDefault:"8"`
+	Timeout  time.Duration     `env:"TIMEOUT" envDefault:"10s"`
+}
+
+// PrometheusConfig configures the scrape endpoint served when "prometheus" is
+// listed in DATABASE_TYPE.
+type PrometheusConfig struct {
+	ListenAddress string `env:"LISTEN_ADDRESS" envDefault:":9101"`
+	Path          string `env:"PATH" envDefault:"/metrics"`
+}
+
+// RemoteWriteConfig configures the Prometheus remote write sink. Username and
+// Password enable basic auth, BearerToken bearer auth.
+type RemoteWriteConfig struct {
+	URL         string `env:"URL" envDefault:"http://localhost:8428/api/v1/write"`
+	Username    string `env:"USERNAME"`
+	Password    string `env:"PASSWORD"`
+	BearerToken string `env:"BEARER_TOKEN"`
+}
+
+// InfluxDBConfig configures the InfluxDB line protocol sink. APIVersion 1
+// writes to /write using Database, RetentionPolicy and basic auth; version 2
+// writes to /api/v2/write using Org, Bucket and Token.
+type InfluxDBConfig struct {
+	URL             string `env:"URL" envDefault:"http://localhost:8086"`
+	APIVersion      int    `env:"API_VERSION" envDefault:"2"`
+	Database        string `env:"DATABASE" envDefault:"system_monitoring"`
+	RetentionPolicy string `env:"RETENTION_POLICY"`
+	Username        string `env:"USERNAME"`
+	Password        string `env:"PASSWORD"`
+	Org             string `env:"ORG"`
+	Bucket          string `env:"BUCKET" envDefault:"system_monitoring"`
+	Token           string `env:"TOKEN"`
+}
+
+// OTLPConfig configures the OpenTelemetry metrics exporter. Protocol is
+// "http/protobuf" or "http/json"; Headers are sent with every request, e.g.
+// "Authorization=Bearer abc,X-Scope-OrgID=site1".
+type OTLPConfig struct {
+	Endpoint    string            `env:"ENDPOINT" envDefault:"http://localhost:4318/v1/metrics"`
+	Protocol    string            `env:"PROTOCOL" envDefault:"http/protobuf"`
+	Headers     map[string]string `env:"HEADERS" envSeparator:"," envKeyValSeparator:"="`
+	ServiceName string            `env:"SERVICE_NAME" envDefault:"system-monitoring"`
+}
+
+// GraphiteConfig configures the Graphite plaintext sink. Series are named by
+// Prefix and Template joined with a dot; both may use {host}, {collector},
+// {name} and {<label>} placeholders. Labels the template does not use are
+// appended as path components, or sent as Graphite tags when Tags is set.
+type GraphiteConfig struct {
+	Address  string `env:"ADDRESS" envDefault:"localhost:2003"`
+	Protocol string `env:"PROTOCOL" envDefault:"tcp"`
+	Prefix   string `env:"PREFIX" envDefault:"system_monitoring.{host}"`
+	Template string `env:"TEMPLATE" envDefault:"{collector}.{name}"`
+	Tags     bool   `env:"TAGS" envDefault:"false"`
+}
+
+// StatsDConfig configures the StatsD sink. Naming works as for Graphite; Tags
+// sends the remaining labels as DogStatsD tags.
+type StatsDConfig struct {
+	Address  string `env:"ADDRESS" envDefault:"localhost:8125"`
+	Prefix   string `env:"PREFIX" envDefault:"system_monitoring.{host}"`
+	Template string `env:"TEMPLATE" envDefault:"{collector}.{name}"`
+	Tags     bool   `env:"TAGS" envDefault:"false"`
+}
+
+// MQTTConfig configures the MQTT sink. Broker is a tcp:// or ssl:// URL.
+// Topic may use {host}, {collector} and {name}; Mode "batch" publishes one
+// JSON document per topic and cycle, "sample" one message per series.
+type MQTTConfig struct {
+	Broker                string `env:"BROKER" envDefault:"tcp://localhost:1883"`
+	ClientID              string `env:"CLIENT_ID"`
+	Username              string `env:"USERNAME"`
+	Password              string `env:"PASSWORD"`
+	Topic                 string `env:"TOPIC" envDefault:"sysmon/{host}/{collector}"`
+	Mode                  string `env:"MODE" envDefault:"batch"`
+	QoS                   int    `env:"QOS" envDefault:"0"`
+	Retained              bool   `env:"RETAINED" envDefault:"false"`
+	TLSCAFile             string `env:"TLS_CA_FILE"`
+	TLSCertFile           string `env:"TLS_CERT_FILE"`
+	TLSKeyFile            string `env:"TLS_KEY_FILE"`
+	TLSInsecureSkipVerify bool   `env:"TLS_INSECURE_SKIP_VERIFY" envDefault:"false"`
+}
+
+// FileSinkConfig configures the local file sink. Format is "jsonl" or "csv".
+// The file is rotated once it reaches MaxSize bytes or when a new
+// RotateInterval period starts; zero disables either. MaxBackups rotated files
+// are kept, all of them when zero.
+type FileSinkConfig struct {
+	Path           string        `env:"PATH" envDefault:"/var/lib/system-monitor/metrics.jsonl"`
+	Format         string        `env:"FORMAT" envDefault:"jsonl"`
+	MaxSize        int64         `env:"MAX_SIZE" envDefault:"104857600"`
+	RotateInterval time.Duration `env:"ROTATE_INTERVAL" envDefault:"24h"`
+	Compress       bool          `env:"COMPRESS" envDefault:"true"`
+	MaxBackups     int           `env:"MAX_BACKUPS" envDefault:"7"`
+}
+
+// BufferConfig configures the on-disk queue for batches a sink failed to
+// deliver. Each sink gets its own directory below Path. The oldest batches
+// are dropped once the queue exceeds MaxSize bytes or MaxAge.
+type BufferConfig struct {
+	Enabled bool          `env:"ENABLED" envDefault:"false"`
+	Path    string        `env:"PATH" envDefault:"/var/lib/system-monitor/buffer"`
+	MaxSize int64         `env:"MAX_SIZE" envDefault:"67108864"`
+	MaxAge  time.Duration `env:"MAX_AGE" envDefault:"24h"`
+}
+
 func (c *Config) GetVictoriaMetricsURL() string {
 	return fmt.Sprintf("%s:%d", c.Database.URL, c.Database.Port)
 }
@@ -50,5 +272,28 @@ func LoadConfig() (*Config, error) {
 	if err := env.Parse(config); err != nil {
 		return nil, err
 	}
+	config.LogWatch.Files = uniqueTrimmed(config.LogWatch.Files)
+	if config.Monitoring.EnableContainers && config.Docker.Timeout <= 0 {
+		return nil, fmt.Errorf("DOCKER_TIMEOUT must be positive, got %s", config.Docker.Timeout)
+	}
+	if config.Monitoring.EnableDirWatch && config.DirWatch.Timeout <= 0 {
+		return nil, fmt.Errorf("DIRWATCH_TIMEOUT must be positive, got %s", config.DirWatch.Timeout)
+	}
 	return config, nil
 }
+
+// uniqueTrimmed trims every value and drops empty and repeated ones, keeping
+// the first occurrence.
+func uniqueTrimmed(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
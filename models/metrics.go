@@ -31,3 +31,287 @@ type SystemTemp struct {
 	TempInC   float64
 	Timestamp time.Time
 }
+
+// HistogramStats holds cumulative bucket counts in the Prometheus layout:
+// Counts[i] is the number of observations less than or equal to Buckets[i].
+type HistogramStats struct {
+	Buckets []float64
+	Counts  []uint64
+	Sum     float64
+	Count   uint64
+}
+
+type LogPatternStats struct {
+	File    string
+	Pattern string
+	Matches int64
+	Values  *HistogramStats // nil unless the pattern has a "value" group
+}
+
+type LogWatchStats struct {
+	Patterns  []LogPatternStats
+	Timestamp time.Time
+}
+
+type KernelEventStats struct {
+	BySeverity map[string]int64
+	ByClass    map[string]int64
+	Timestamp  time.Time
+}
+
+type MDArrayStats struct {
+	Name            string
+	State           string
+	Level           string
+	RequiredDisks   int
+	ActiveDisks     int
+	FailedDisks     int
+	SpareDisks      int
+	SyncAction      string // empty when no resync, recovery or check is running
+	SyncProgressPct float64
+}
+
+type BlockDeviceStats struct {
+	Name       string
+	Rotational bool
+	SizeBytes  int64
+}
+
+type StorageHealthStats struct {
+	Arrays    []MDArrayStats
+	Devices   []BlockDeviceStats
+	Timestamp time.Time
+}
+
+type InventoryStats struct {
+	Hostname      string
+	KernelRelease string
+	OSName        string
+	OSVersion     string
+	CPUModel      string
+	CPUCores      int
+	CPUThreads    int
+	SystemVendor  string
+	ProductName   string
+	BootTime      time.Time
+	Uptime        time.Duration
+	Timestamp     time.Time
+}
+
+type TimexStats struct {
+	Offset       time.Duration
+	FrequencyPPM float64
+	MaxError     time.Duration
+	EstError     time.Duration
+	Synced       bool
+	Timestamp    time.Time
+}
+
+// PowerSupplyStats describes one entry of /sys/class/power_supply. Attributes
+// the driver does not expose are left nil.
+type PowerSupplyStats struct {
+	Name         string
+	Type         string
+	Status       string
+	Online       *bool
+	CapacityPct  *float64
+	VoltageVolts *float64
+	CycleCount   *int64
+}
+
+type RAPLZoneStats struct {
+	Zone         string
+	Name         string
+	EnergyJoules float64
+	PowerWatts   float64
+	HasPower     bool // false on the first reading of a zone
+}
+
+type PowerStats struct {
+	Supplies  []PowerSupplyStats
+	RAPLZones []RAPLZoneStats
+	Timestamp time.Time
+}
+
+type ContainerStats struct {
+	ID                    string
+	Name                  string
+	Image                 string
+	CPUSeconds            float64
+	CPUPercent            float64
+	MemoryUsageBytes      uint64
+	MemoryWorkingSetBytes uint64
+	MemoryLimitBytes      uint64
+	NetworkRxBytes        uint64
+	NetworkTxBytes        uint64
+	BlockReadBytes        uint64
+	BlockWriteBytes       uint64
+}
+
+type ContainersStats struct {
+	Containers []ContainerStats
+	Timestamp  time.Time
+}
+
+// InterruptCounter is one interrupt or softirq count. IRQ is empty when counts
+// were summed over all IRQs and CPU is empty when summed over all CPUs.
+type InterruptCounter struct {
+	IRQ   string
+	Info  string
+	CPU   string
+	Count uint64
+}
+
+type InterruptsStats struct {
+	Interrupts []InterruptCounter
+	Softirqs   []InterruptCounter
+	Timestamp  time.Time
+}
+
+type SchedCPUStats struct {
+	CPU        string
+	Running    time.Duration
+	Waiting    time.Duration
+	Timeslices uint64
+}
+
+type SchedStats struct {
+	CPUs      []SchedCPUStats
+	Timestamp time.Time
+}
+
+type WirelessStats struct {
+	Interface string
+	Quality   float64
+	SignalDBm float64
+	NoiseDBm  float64
+	HasNoise  bool // drivers that cannot measure noise report -256
+}
+
+type BondSlaveStats struct {
+	Name         string
+	Up           bool
+	Active       bool
+	LinkFailures uint64
+}
+
+type BondStats struct {
+	Name   string
+	Mode   string
+	Up     bool
+	Slaves []BondSlaveStats
+}
+
+type BridgePortStats struct {
+	Bridge    string
+	Port      string
+	State     int
+	StateName string
+}
+
+type NetworkLinkStats struct {
+	Wireless    []WirelessStats
+	Bonds       []BondStats
+	BridgePorts []BridgePortStats
+	Timestamp   time.Time
+}
+
+type NUMANodeStats struct {
+	Node  string
+	Total int64 // bytes
+	Free  int64
+	Used  int64
+	Stats map[string]uint64 // numastat counters, e.g. numa_hit
+}
+
+type NUMAStats struct {
+	Nodes     []NUMANodeStats
+	Timestamp time.Time
+}
+
+type EDACCsrowStats struct {
+	Csrow         string
+	Correctable   uint64
+	Uncorrectable uint64
+}
+
+type EDACControllerStats struct {
+	Controller          string
+	Name                string
+	Correctable         uint64
+	Uncorrectable       uint64
+	CorrectableNoInfo   uint64 // errors the driver could not attribute to a csrow
+	UncorrectableNoInfo uint64
+	Csrows              []EDACCsrowStats
+}
+
+type EDACStats struct {
+	Controllers []EDACControllerStats
+	Timestamp   time.Time
+}
+
+type UserSessionCount struct {
+	User     string
+	Terminal string // "pts", "tty" or "other"
+	Sessions int
+}
+
+type SessionStats struct {
+	Active    []UserSessionCount
+	Logins    map[string]uint64 // logins seen since startup, per user
+	Timestamp time.Time
+}
+
+type ListenerStats struct {
+	Protocol string // tcp, tcp6, udp or udp6
+	Address  string
+	Port     int
+	Process  string // empty when the owning process could not be determined
+	PID      int
+}
+
+type ListenersStats struct {
+	Listeners []ListenerStats
+	Opened    uint64 // listeners that appeared since startup
+	Closed    uint64 // listeners that vanished since startup
+	Timestamp time.Time
+}
+
+type IntegrityPathStats struct {
+	Path            string
+	WatchedFiles    int
+	UnhashableFiles int // files whose content could not be read this cycle
+	Changes         uint64
+	LastChangeTime  time.Time // zero until the first change
+}
+
+type IntegrityStats struct {
+	Paths     []IntegrityPathStats
+	Timestamp time.Time
+}
+
+type DirStats struct {
+	Path             string
+	Pattern          string
+	SizeBytes        int64
+	FileCount        int
+	Newest           time.Time // zero when no file matched
+	Oldest           time.Time
+	Incomplete       bool   // the walk hit a limit or the timeout, or failed
+	IncompleteReason string // the first limit hit, for the log
+}
+
+type DirWatchStats struct {
+	Dirs      []DirStats
+	Timestamp time.Time
+}
+
+type SinkBufferStats struct {
+	Sink            string
+	QueuedBatches   int
+	QueuedBytes     int64
+	OldestAge       time.Duration // zero when the queue is empty
+	DroppedBatches  uint64        // batches discarded for size or age since startup
+	DroppedSamples  uint64
+	ReplayedBatches uint64
+}